@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// APIKey adalah satu entri di file api keys. Yang disimpan hanya hash
+// sha256 (hex) dari key mentah, misal hasil `echo -n <key> | sha256sum`.
+type APIKey struct {
+	Id      string `json:"id" yaml:"id"`
+	Name    string `json:"name" yaml:"name"`
+	KeyHash string `json:"key_hash" yaml:"key_hash"`
+	// Endpoints berisi path yang boleh diakses, kosong berarti semua laporan.
+	// Path dicocokkan persis, akhiran /* membuka semua path di bawahnya.
+	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+	// Tahun membatasi tahun laporan, kosong berarti semua tahun
+	Tahun []int `json:"tahun" yaml:"tahun"`
+	// KodeOpd membatasi data ke satu OPD, kosong berarti seluruh pemda
+	KodeOpd string `json:"kode_opd" yaml:"kode_opd"`
+	Revoked bool   `json:"revoked" yaml:"revoked"`
+}
+
+type apiKeyFile struct {
+	Keys []APIKey `json:"keys" yaml:"keys"`
+}
+
+type apiKeyUsage struct {
+	requests atomic.Int64
+	denied   atomic.Int64
+	lastUsed atomic.Int64 // unix detik
+}
+
+type APIKeyUsage struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Revoked  bool   `json:"revoked"`
+	Requests int64  `json:"requests"`
+	Denied   int64  `json:"denied"`
+	LastUsed string `json:"last_used,omitempty"`
+}
+
+type apiKeyStore struct {
+	path string
+
+	mu      sync.RWMutex
+	byHash  map[string]APIKey
+	byId    map[string]APIKey
+	modTime time.Time
+
+	// counter disimpan terpisah supaya tidak hilang saat file dibaca ulang
+	usage sync.Map // id -> *apiKeyUsage
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadAPIKeyStore(path string) (*apiKeyStore, error) {
+	s := &apiKeyStore{path: path}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload membaca ulang file bila waktu modifikasinya berubah.
+func (s *apiKeyStore) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("stat api keys file: %w", err)
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	var f apiKeyFile
+	if err := decodeFile(s.path, &f); err != nil {
+		return err
+	}
+
+	byHash := make(map[string]APIKey, len(f.Keys))
+	byId := make(map[string]APIKey, len(f.Keys))
+	for _, key := range f.Keys {
+		if key.Id == "" || len(key.KeyHash) != sha256.Size*2 {
+			return fmt.Errorf("api key %q: id wajib diisi dan key_hash harus sha256 hex", key.Id)
+		}
+		if _, dup := byId[key.Id]; dup {
+			return fmt.Errorf("api key %q terdaftar lebih dari sekali", key.Id)
+		}
+		byHash[strings.ToLower(key.KeyHash)] = key
+		byId[key.Id] = key
+	}
+
+	s.mu.Lock()
+	s.byHash = byHash
+	s.byId = byId
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	log.Printf("API keys dimuat dari %s: %d key", s.path, len(f.Keys))
+	return nil
+}
+
+// watch membaca ulang file secara berkala sampai ctx dibatalkan.
+// Bila file rusak, daftar key lama tetap dipakai.
+func (s *apiKeyStore) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reload(); err != nil {
+				log.Printf("[WARN] gagal membaca ulang api keys: %v", err)
+			}
+		}
+	}
+}
+
+func (s *apiKeyStore) lookup(raw string) (APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.byHash[hashAPIKey(raw)]
+	if !ok || key.Revoked {
+		return APIKey{}, false
+	}
+	return key, true
+}
+
+func (s *apiKeyStore) usageOf(id string) *apiKeyUsage {
+	u, _ := s.usage.LoadOrStore(id, &apiKeyUsage{})
+	return u.(*apiKeyUsage)
+}
+
+func endpointMatches(pattern, path string) bool {
+	if base, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(path, base+"/")
+	}
+	return path == pattern
+}
+
+// allows memeriksa scope endpoint dari key terhadap request. Scope tahun
+// diperiksa per endpoint lewat tahunScope saat route didaftarkan.
+func (k APIKey) allows(r *http.Request) error {
+	if len(k.Endpoints) > 0 {
+		allowed := slices.ContainsFunc(k.Endpoints, func(e string) bool {
+			return endpointMatches(e, r.URL.Path)
+		})
+		if !allowed {
+			return fmt.Errorf("endpoint %s di luar scope api key", r.URL.Path)
+		}
+	}
+	return nil
+}
+
+func (k APIKey) principal() Principal {
+	return Principal{
+		Subject: "apikey:" + k.Id,
+		APIKey:  k.Id,
+		KodeOpd: k.KodeOpd,
+		Bappeda: k.KodeOpd == "",
+		Tahun:   k.Tahun,
+	}
+}
+
+// authenticate memverifikasi api key dari header. handled=false bila
+// request tidak membawa api key sehingga perlu diperiksa dengan cara lain.
+func (s *apiKeyStore) authenticate(header string, w http.ResponseWriter, r *http.Request) (Principal, bool, bool) {
+	raw := r.Header.Get(header)
+	if raw == "" {
+		return Principal{}, false, false
+	}
+
+	key, ok := s.lookup(raw)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "api key tidak valid atau sudah dicabut")
+		return Principal{}, true, false
+	}
+
+	usage := s.usageOf(key.Id)
+	usage.lastUsed.Store(time.Now().Unix())
+
+	if err := key.allows(r); err != nil {
+		usage.denied.Add(1)
+		writeError(w, http.StatusForbidden, "akses ditolak: "+err.Error())
+		return Principal{}, true, false
+	}
+
+	usage.requests.Add(1)
+	return key.principal(), true, true
+}
+
+func (s *apiKeyStore) usageReport() []APIKeyUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := make([]APIKeyUsage, 0, len(s.byId))
+	for id, key := range s.byId {
+		u := s.usageOf(id)
+		item := APIKeyUsage{
+			Id:       id,
+			Name:     key.Name,
+			Revoked:  key.Revoked,
+			Requests: u.requests.Load(),
+			Denied:   u.denied.Load(),
+		}
+		if last := u.lastUsed.Load(); last > 0 {
+			item.LastUsed = time.Unix(last, 0).Format(time.RFC3339)
+		}
+		report = append(report, item)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Id < report[j].Id })
+	return report
+}
+
+// apiKeyUsageHandler menampilkan counter pemakaian per key, khusus role Bappeda.
+func (s *apiKeyStore) apiKeyUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed, pakai GET")
+		return
+	}
+
+	p, ok := principalFrom(r.Context())
+	if !ok || !p.Bappeda || p.APIKey != "" {
+		writeError(w, http.StatusForbidden, "akses ditolak: khusus role bappeda")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Status  int           `json:"status"`
+		Message string        `json:"message"`
+		Data    []APIKeyUsage `json:"data"`
+	}{http.StatusOK, "Pemakaian API key", s.usageReport()})
+}
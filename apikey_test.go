@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyAllowsEndpoint(t *testing.T) {
+	key := APIKey{Endpoints: []string{"/laporan/cascading_pemda", "/laporan/cascading_pemda/pagu/*"}}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"/laporan/cascading_pemda", true},
+		{"/laporan/cascading_pemda/batch", false},
+		{"/laporan/cascading_pemda/pegawai", false},
+		{"/laporan/cascading_pemda/pagu", false},
+		{"/laporan/cascading_pemda/pagu/rekonsiliasi", true},
+		{"/laporan/cascading_pemdax", false},
+	}
+	for _, tt := range tests {
+		err := key.allows(httptest.NewRequest("GET", tt.path, nil))
+		if (err == nil) != tt.ok {
+			t.Errorf("allows(%s) error = %v, want ok=%v", tt.path, err, tt.ok)
+		}
+	}
+}
+
+func TestLaporanRoutesTahunScope(t *testing.T) {
+	key := APIKey{Id: "k", KeyHash: hashAPIKey("rahasia"), Tahun: []int{2025}}
+	store := &apiKeyStore{
+		byHash: map[string]APIKey{key.KeyHash: key},
+		byId:   map[string]APIKey{key.Id: key},
+	}
+	authCfg := defaultConfig().Auth
+	authenticate, err := newAuthMiddleware(authCfg, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(rt laporanRoute, method, target, body string) int {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set(authCfg.APIKeyHeader, "rahasia")
+		w := httptest.NewRecorder()
+		laporanHandler(rt, authenticate, nil).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// database tidak siap: request yang lolos scope tahun berhenti di 503
+	routes := laporanRoutes()
+	if len(routes) == 0 {
+		t.Fatal("tidak ada route laporan")
+	}
+	for _, rt := range routes {
+		if !rt.tahun.declared() {
+			t.Errorf("%s tidak menyatakan sumber tahun", rt.path)
+			continue
+		}
+		if rt.tahun.inHandler {
+			continue
+		}
+
+		allowed := url.Values{}
+		for _, param := range rt.tahun.params {
+			allowed.Set(param, "2025")
+		}
+		if code := serve(rt, "GET", rt.path+"?"+allowed.Encode(), ""); code != http.StatusServiceUnavailable {
+			t.Errorf("%s tahun 2025: status = %d, want lolos ke cek database", rt.path, code)
+		}
+		if code := serve(rt, "GET", rt.path, ""); code != http.StatusForbidden {
+			t.Errorf("%s tanpa tahun: status = %d, want 403", rt.path, code)
+		}
+		for _, param := range rt.tahun.params {
+			denied := url.Values{}
+			maps.Copy(denied, allowed)
+			denied.Set(param, "2024")
+			if code := serve(rt, "GET", rt.path+"?"+denied.Encode(), ""); code != http.StatusForbidden {
+				t.Errorf("%s %s=2024: status = %d, want 403", rt.path, param, code)
+			}
+		}
+	}
+
+	// batch membaca tahun dari body, diperiksa handler
+	saved := cfg.Batch
+	defer func() { cfg.Batch = saved }()
+	cfg.Batch = defaultConfig().Batch
+	ctx := withPrincipal(context.Background(), key.principal())
+	r := httptest.NewRequest("POST", "/laporan/cascading_pemda/batch", strings.NewReader(`{"tematik_ids":[1],"tahun":2024}`))
+	w := httptest.NewRecorder()
+	cascadingBatchHandler(w, r.WithContext(ctx))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("batch tahun 2024 di body: status = %d, want 403", w.Code)
+	}
+}
+
+func TestTahunAllowed(t *testing.T) {
+	if !tahunAllowed(context.Background(), 2020) {
+		t.Error("tanpa auth semua tahun harus boleh")
+	}
+	ctx := withPrincipal(context.Background(), APIKey{Id: "k", Tahun: []int{2025}}.principal())
+	if !tahunAllowed(ctx, 2025) || tahunAllowed(ctx, 2024) {
+		t.Error("scope tahun api key tidak dihormati")
+	}
+}
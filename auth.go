@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Principal adalah identitas pemanggil hasil verifikasi token.
+type Principal struct {
+	Subject string
+	Roles   []string
+	KodeOpd string
+	Bappeda bool
+	// APIKey berisi id key bila pemanggil masuk lewat api key
+	APIKey string
+	// Tahun membatasi tahun yang boleh dibaca, kosong berarti semua
+	Tahun []int
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// principalFrom mengembalikan identitas pemanggil, ok=false bila auth tidak aktif.
+func principalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// tahunAllowed memeriksa scope tahun pemanggil.
+func tahunAllowed(ctx context.Context, tahun int) bool {
+	p, ok := principalFrom(ctx)
+	return !ok || len(p.Tahun) == 0 || slices.Contains(p.Tahun, tahun)
+}
+
+// tahunScope menyatakan dari mana tahun sebuah endpoint laporan dibaca.
+// Setiap endpoint wajib menyatakannya saat didaftarkan supaya scope tahun
+// pemanggil tidak bergantung pada handler yang ingat memeriksanya.
+type tahunScope struct {
+	// params berisi parameter query yang memuat tahun, semuanya diperiksa
+	params []string
+	// inHandler: tahun ada di body atau per node, handler memeriksanya
+	// sendiri lewat tahunAllowed
+	inHandler bool
+}
+
+func tahunQuery(params ...string) tahunScope {
+	return tahunScope{params: params}
+}
+
+// tahunDiHandler dipakai batch (tahun di body) dan lineage (semua tahun).
+var tahunDiHandler = tahunScope{inHandler: true}
+
+func (s tahunScope) declared() bool {
+	return s.inHandler || len(s.params) > 0
+}
+
+// middleware menolak request yang tahunnya di luar scope pemanggil.
+func (s tahunScope) middleware(next http.Handler) http.Handler {
+	if s.inHandler {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := principalFrom(r.Context()); ok && len(p.Tahun) > 0 {
+			for _, param := range s.params {
+				tahun, err := strconv.Atoi(r.URL.Query().Get(param))
+				if err != nil || !slices.Contains(p.Tahun, tahun) {
+					writeError(w, http.StatusForbidden, "akses ditolak: tahun di luar scope")
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// restrictedOpd mengembalikan kode_opd yang boleh dilihat pemanggil,
+// string kosong berarti boleh melihat semua.
+func restrictedOpd(ctx context.Context) string {
+	p, ok := principalFrom(ctx)
+	if !ok || p.Bappeda {
+		return ""
+	}
+	return p.KodeOpd
+}
+
+func (k JWTKey) validate() error {
+	switch {
+	case strings.HasPrefix(k.Algorithm, "HS"):
+		if k.Secret == "" {
+			return fmt.Errorf("auth key %q: secret wajib diisi untuk %s", k.Kid, k.Algorithm)
+		}
+	case strings.HasPrefix(k.Algorithm, "RS"):
+		if k.PublicKey == "" && k.PublicKeyFile == "" {
+			return fmt.Errorf("auth key %q: public_key atau public_key_file wajib diisi untuk %s", k.Kid, k.Algorithm)
+		}
+	default:
+		return fmt.Errorf("auth key %q: algoritma %q tidak didukung", k.Kid, k.Algorithm)
+	}
+	if jwt.GetSigningMethod(k.Algorithm) == nil {
+		return fmt.Errorf("auth key %q: algoritma %q tidak dikenal", k.Kid, k.Algorithm)
+	}
+	return nil
+}
+
+type verificationKey struct {
+	kid string
+	alg string
+	key any
+}
+
+type keySet struct {
+	keys []verificationKey
+	algs []string
+}
+
+func loadKeySet(keys []JWTKey) (*keySet, error) {
+	ks := &keySet{}
+	for _, k := range keys {
+		vk := verificationKey{kid: k.Kid, alg: k.Algorithm}
+
+		if strings.HasPrefix(k.Algorithm, "HS") {
+			vk.key = []byte(k.Secret)
+		} else {
+			pem := []byte(k.PublicKey)
+			if k.PublicKeyFile != "" {
+				data, err := os.ReadFile(k.PublicKeyFile)
+				if err != nil {
+					return nil, fmt.Errorf("baca public key %q: %w", k.Kid, err)
+				}
+				pem = data
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, fmt.Errorf("parse public key %q: %w", k.Kid, err)
+			}
+			vk.key = pub
+		}
+
+		ks.keys = append(ks.keys, vk)
+		if !slices.Contains(ks.algs, k.Algorithm) {
+			ks.algs = append(ks.algs, k.Algorithm)
+		}
+	}
+	return ks, nil
+}
+
+// keyFunc memilih kunci berdasarkan header kid, atau satu-satunya kunci
+// dengan algoritma yang sama bila token tidak membawa kid.
+func (ks *keySet) keyFunc(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	kid, _ := token.Header["kid"].(string)
+
+	var candidate *verificationKey
+	for i, k := range ks.keys {
+		if k.alg != alg {
+			continue
+		}
+		if kid != "" && k.kid == kid {
+			return k.key, nil
+		}
+		if kid == "" {
+			if candidate != nil {
+				return nil, errors.New("token tanpa kid, kunci ambigu")
+			}
+			candidate = &ks.keys[i]
+		}
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("kunci untuk kid %q alg %s tidak ditemukan", kid, alg)
+	}
+	return candidate.key, nil
+}
+
+func claimStrings(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return splitList(val)
+	case []any:
+		var out []string
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (a AuthConfig) principal(claims jwt.MapClaims) Principal {
+	p := Principal{Roles: claimStrings(claims[a.RolesClaim])}
+	p.Subject, _ = claims.GetSubject()
+	if opd, ok := claims[a.OpdClaim].(string); ok {
+		p.KodeOpd = opd
+	}
+	for _, role := range p.Roles {
+		if slices.Contains(a.BappedaRoles, role) {
+			p.Bappeda = true
+		}
+	}
+	return p
+}
+
+// newAuthMiddleware membuat middleware yang memverifikasi api key (bila
+// apiKeys tidak nil) atau bearer JWT dan menyimpan Principal di context.
+// Role Bappeda boleh melihat semua, role lain wajib membawa claim kode_opd.
+func newAuthMiddleware(authCfg AuthConfig, apiKeys *apiKeyStore) (func(http.Handler) http.Handler, error) {
+	if !authCfg.Enabled {
+		log.Print("[WARN] autentikasi JWT tidak aktif, semua laporan terbuka untuk umum")
+	}
+
+	ks, err := loadKeySet(authCfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(ks.algs),
+		jwt.WithLeeway(time.Duration(authCfg.Leeway)),
+		jwt.WithExpirationRequired(),
+	}
+	if authCfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(authCfg.Issuer))
+	}
+	if authCfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(authCfg.Audience))
+	}
+	parser := jwt.NewParser(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKeys != nil {
+				p, handled, ok := apiKeys.authenticate(authCfg.APIKeyHeader, w, r)
+				if handled {
+					if ok {
+						next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+					}
+					return
+				}
+			}
+
+			if !authCfg.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || strings.TrimSpace(raw) == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="cascading-pemda"`)
+				writeError(w, http.StatusUnauthorized, "token tidak ditemukan, kirim lewat header Authorization: Bearer")
+				return
+			}
+
+			claims := jwt.MapClaims{}
+			if _, err := parser.ParseWithClaims(strings.TrimSpace(raw), claims, ks.keyFunc); err != nil {
+				log.Printf("[WARN] token ditolak: %v", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="cascading-pemda", error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "token tidak valid")
+				return
+			}
+
+			p := authCfg.principal(claims)
+			if !p.Bappeda && p.KodeOpd == "" {
+				writeError(w, http.StatusForbidden, "akses ditolak: token tidak memiliki role bappeda maupun kode_opd")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+		})
+	}, nil
+}
+
+// restrictTreeToOpd memangkas pohon sehingga hanya cabang yang memuat node
+// milik kodeOpd yang tersisa. Node leluhur dipertahankan sebagai konteks:
+// rencana kinerja, indikator, tagging dan agregat (program s.d. subkegiatan)
+// yang dihitung dari seluruh pohon dikosongkan, dan pagu dihitung ulang
+// dari cabang yang terlihat saja.
+func restrictTreeToOpd(nodes []PohonKinerjaPemda, kodeOpd string) ([]PohonKinerjaPemda, Pagu, error) {
+	var kept []PohonKinerjaPemda
+	var total paguSum
+
+	for _, node := range nodes {
+		if node.KodeOpd == kodeOpd {
+			kept = append(kept, node)
+			total.add(node.Pagu)
+			continue
+		}
+
+		childs, childPagu, err := restrictTreeToOpd(node.Childs, kodeOpd)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(childs) == 0 {
+			continue
+		}
+		node.Childs = childs
+		clearContextNode(&node)
+		node.Pagu = childPagu
+
+		kept = append(kept, node)
+		total.add(childPagu)
+	}
+	if total.err != nil {
+		return nil, 0, fmt.Errorf("pagu OPD %s: %w", kodeOpd, total.err)
+	}
+
+	return kept, total.total, nil
+}
+
+// clearContextNode mengosongkan data node leluhur yang bisa memuat data OPD
+// lain. Nama, jenis dan level tetap ada supaya jalur cabangnya terbaca.
+// TujuanPemda dan SasaranPemda sengaja dibiarkan: keduanya dokumen RPJMD
+// milik pemda yang sama untuk semua OPD, bukan data OPD lain, dan justru
+// menjelaskan ke mana cabang OPD itu berkontribusi.
+func clearContextNode(node *PohonKinerjaPemda) {
+	node.RencanaKinerjas = nil
+	node.Indikators = nil
+	node.Tagging = nil
+	node.ProgramPokin = nil
+	node.BidangUrusanPokin = nil
+	node.UrusanPokin = nil
+	node.KegiatanPokin = nil
+	node.SubkegiatanPokin = nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testAuthConfig() AuthConfig {
+	authCfg := defaultConfig().Auth
+	authCfg.Enabled = true
+	authCfg.Issuer = "kinerja"
+	authCfg.Audience = "cascading"
+	authCfg.Keys = []JWTKey{
+		{Kid: "lama", Algorithm: "HS256", Secret: "rahasia-lama"},
+		{Kid: "baru", Algorithm: "HS256", Secret: "rahasia-baru"},
+	}
+	return authCfg
+}
+
+func signToken(t *testing.T, kid, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "199001012020011001",
+		"iss":   "kinerja",
+		"aud":   "cascading",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []any{"admin_bappeda"},
+	}
+}
+
+func serveAuth(t *testing.T, authCfg AuthConfig, bearer string) (*httptest.ResponseRecorder, Principal, bool) {
+	t.Helper()
+	mw, err := newAuthMiddleware(authCfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Principal
+	var reached bool
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = principalFrom(r.Context())
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest("GET", "/laporan/cascading_pemda?tahun=2025", nil)
+	if bearer != "" {
+		r.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, got, reached
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authCfg := testAuthConfig()
+
+	opd := validClaims()
+	opd["roles"] = []any{"admin_opd"}
+	opd["kode_opd"] = "5.01.5.05.0.00.01.0000"
+
+	noScope := validClaims()
+	noScope["roles"] = []any{"admin_opd"}
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	wrongIss := validClaims()
+	wrongIss["iss"] = "lain"
+
+	wrongAud := validClaims()
+	wrongAud["aud"] = "lain"
+
+	tests := []struct {
+		name   string
+		bearer string
+		status int
+	}{
+		{"tanpa token", "", http.StatusUnauthorized},
+		{"kid baru", signToken(t, "baru", "rahasia-baru", validClaims()), http.StatusOK},
+		{"kid lama", signToken(t, "lama", "rahasia-lama", validClaims()), http.StatusOK},
+		{"kid cocok secret salah", signToken(t, "baru", "rahasia-lama", validClaims()), http.StatusUnauthorized},
+		{"kid tidak dikenal", signToken(t, "asing", "rahasia-baru", validClaims()), http.StatusUnauthorized},
+		{"tanpa kid kunci ambigu", signToken(t, "", "rahasia-baru", validClaims()), http.StatusUnauthorized},
+		{"kedaluwarsa", signToken(t, "baru", "rahasia-baru", expired), http.StatusUnauthorized},
+		{"issuer salah", signToken(t, "baru", "rahasia-baru", wrongIss), http.StatusUnauthorized},
+		{"audience salah", signToken(t, "baru", "rahasia-baru", wrongAud), http.StatusUnauthorized},
+		{"role opd dengan kode_opd", signToken(t, "baru", "rahasia-baru", opd), http.StatusOK},
+		{"tanpa role bappeda dan kode_opd", signToken(t, "baru", "rahasia-baru", noScope), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w, _, reached := serveAuth(t, authCfg, tt.bearer)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.status, w.Body.String())
+		}
+		if reached != (tt.status == http.StatusOK) {
+			t.Errorf("%s: handler terpanggil = %v", tt.name, reached)
+		}
+		if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 tanpa header WWW-Authenticate", tt.name)
+		}
+	}
+
+	_, p, _ := serveAuth(t, authCfg, signToken(t, "baru", "rahasia-baru", opd))
+	if p.Bappeda || p.KodeOpd != "5.01.5.05.0.00.01.0000" || restrictedOpd(withPrincipal(t.Context(), p)) != p.KodeOpd {
+		t.Errorf("principal opd = %+v", p)
+	}
+	_, p, _ = serveAuth(t, authCfg, signToken(t, "baru", "rahasia-baru", validClaims()))
+	if !p.Bappeda || p.Subject != "199001012020011001" {
+		t.Errorf("principal bappeda = %+v", p)
+	}
+}
+
+func TestAuthMiddlewareSingleKeyWithoutKid(t *testing.T) {
+	authCfg := testAuthConfig()
+	authCfg.Keys = authCfg.Keys[1:]
+
+	w, _, _ := serveAuth(t, authCfg, signToken(t, "", "rahasia-baru", validClaims()))
+	if w.Code != http.StatusOK {
+		t.Errorf("satu kunci tanpa kid: status = %d, want 200", w.Code)
+	}
+}
+
+func TestKeySetAmbiguousKid(t *testing.T) {
+	ks, err := loadKeySet(testAuthConfig().Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ks.keyFunc(jwt.New(jwt.SigningMethodHS256))
+	if err == nil || err.Error() != "token tanpa kid, kunci ambigu" {
+		t.Errorf("keyFunc tanpa kid error = %v, want kunci ambigu", err)
+	}
+}
+
+func TestAuthMiddlewareDisabled(t *testing.T) {
+	authCfg := testAuthConfig()
+	authCfg.Enabled = false
+
+	w, _, reached := serveAuth(t, authCfg, "")
+	if w.Code != http.StatusOK || !reached {
+		t.Fatalf("auth mati: status = %d, want 200", w.Code)
+	}
+	// token rusak pun diabaikan bila auth mati
+	w, _, _ = serveAuth(t, authCfg, "bukan-token")
+	if w.Code != http.StatusOK {
+		t.Errorf("auth mati dengan token rusak: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRestrictTreeToOpd(t *testing.T) {
+	const opdA, opdB = "OPD-A", "OPD-B"
+	contextData := func(pt PohonKinerjaPemda) PohonKinerjaPemda {
+		pt.RencanaKinerjas = []RencanaKinerjaAsn{{IdRekin: "R-" + pt.NamaPohon}}
+		pt.Indikators = []IndikatorPohon{{Indikator: "indikator " + pt.NamaPohon}}
+		pt.Tagging = []TaggingPokin{{NamaTagging: "tag"}}
+		pt.UrusanPokin = []Urusan{{KodeUrusan: "1"}}
+		pt.BidangUrusanPokin = []BidangUrusan{{KodeBidangUrusan: "1.01"}}
+		pt.ProgramPokin = []Program{{KodeProgram: "1.01.01"}}
+		pt.KegiatanPokin = []Kegiatan{{KodeKegiatan: "1.01.01.2.01"}}
+		pt.SubkegiatanPokin = []Subkegiatan{{KodeSubkegiatan: "1.01.01.2.01.0001"}}
+		pt.SasaranPemda = []SasaranPemda{{SasaranPemda: "sasaran " + pt.NamaPohon}}
+		pt.TujuanPemda = []TujuanPemda{{TujuanPemda: "tujuan " + pt.NamaPohon}}
+		return pt
+	}
+
+	milikA := PohonKinerjaPemda{NamaPohon: "milik A", KodeOpd: opdA, Pagu: 100,
+		RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "RA", Pagu: 100}}}
+	milikB := PohonKinerjaPemda{NamaPohon: "milik B", KodeOpd: opdB, Pagu: 900}
+	tree := []PohonKinerjaPemda{
+		contextData(PohonKinerjaPemda{NamaPohon: "tematik", Pagu: 1040, Childs: []PohonKinerjaPemda{
+			contextData(PohonKinerjaPemda{NamaPohon: "subtematik", Pagu: 1040, Childs: []PohonKinerjaPemda{
+				milikA,
+				milikB,
+				{NamaPohon: "milik A juga", KodeOpd: opdA, Pagu: 40},
+			}}),
+		}}),
+		contextData(PohonKinerjaPemda{NamaPohon: "tematik B", Pagu: 900, Childs: []PohonKinerjaPemda{milikB}}),
+	}
+
+	tests := []struct {
+		name      string
+		kodeOpd   string
+		total     Pagu
+		tematik   int
+		leafNames []string
+	}{
+		{"opd A", opdA, 140, 1, []string{"milik A", "milik A juga"}},
+		{"opd B", opdB, 1800, 2, []string{"milik B"}},
+		{"opd tanpa node", "OPD-C", 0, 0, nil},
+	}
+	for _, tt := range tests {
+		got, total, err := restrictTreeToOpd(tree, tt.kodeOpd)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if total != tt.total || len(got) != tt.tematik {
+			t.Errorf("%s: total = %d dengan %d tematik, want %d dengan %d", tt.name, total, len(got), tt.total, tt.tematik)
+			continue
+		}
+		if tt.tematik == 0 {
+			continue
+		}
+
+		tematik := got[0]
+		sub := tematik.Childs[0]
+		for _, ctxNode := range []PohonKinerjaPemda{tematik, sub} {
+			if ctxNode.RencanaKinerjas != nil || ctxNode.Indikators != nil || ctxNode.Tagging != nil ||
+				ctxNode.UrusanPokin != nil || ctxNode.BidangUrusanPokin != nil || ctxNode.ProgramPokin != nil ||
+				ctxNode.KegiatanPokin != nil || ctxNode.SubkegiatanPokin != nil {
+				t.Errorf("%s: node konteks %s masih membawa data: %+v", tt.name, ctxNode.NamaPohon, ctxNode)
+			}
+			// sasaran/tujuan pemda milik pemda, tetap tampil sebagai konteks
+			if ctxNode.SasaranPemda == nil || ctxNode.TujuanPemda == nil {
+				t.Errorf("%s: sasaran/tujuan pemda %s ikut terhapus", tt.name, ctxNode.NamaPohon)
+			}
+		}
+		if tt.kodeOpd == opdA {
+			if tematik.Pagu != 140 || sub.Pagu != 140 {
+				t.Errorf("%s: pagu konteks = %d/%d, want 140 dari cabang terlihat", tt.name, tematik.Pagu, sub.Pagu)
+			}
+			var names []string
+			for _, leaf := range sub.Childs {
+				names = append(names, leaf.NamaPohon)
+			}
+			if len(names) != len(tt.leafNames) || names[0] != tt.leafNames[0] || names[1] != tt.leafNames[1] {
+				t.Errorf("%s: cabang = %v, want %v", tt.name, names, tt.leafNames)
+			}
+			// node milik OPD sendiri utuh
+			if len(sub.Childs[0].RencanaKinerjas) != 1 {
+				t.Errorf("%s: rekin node milik OPD ikut terhapus", tt.name)
+			}
+		}
+	}
+
+	// pohon asli tidak ikut berubah
+	if tree[0].RencanaKinerjas == nil || tree[0].Pagu != 1040 {
+		t.Error("restrictTreeToOpd mengubah pohon masukan")
+	}
+}
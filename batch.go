@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type BatchRequest struct {
+	TematikIds []int `json:"tematik_ids"`
+	Tahun      int   `json:"tahun"`
+}
+
+// BatchResult adalah hasil per tematik. Error satu tematik tidak
+// menggagalkan tematik lain di batch yang sama.
+type BatchResult struct {
+	TematikId int    `json:"tematik_id"`
+	Status    int    `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Data      any    `json:"data,omitempty"`
+}
+
+type CascadingBatch struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    []BatchResult `json:"data"`
+}
+
+// parseBatchRequest membaca tematikIds dan tahun dari query (GET)
+// atau body JSON (POST). tahun di query tetap dihormati untuk POST.
+func parseBatchRequest(w http.ResponseWriter, r *http.Request) (BatchRequest, error) {
+	var req BatchRequest
+
+	switch r.Method {
+	case http.MethodGet:
+		for _, v := range splitList(r.URL.Query().Get("tematikIds")) {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				return req, fmt.Errorf("invalid tematikIds %q", v)
+			}
+			req.TematikIds = append(req.TematikIds, id)
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			return req, fmt.Errorf("body tidak valid: %v", err)
+		}
+	}
+
+	if v := r.URL.Query().Get("tahun"); v != "" {
+		tahun, err := strconv.Atoi(v)
+		if err != nil {
+			return req, fmt.Errorf("invalid tahun")
+		}
+		if req.Tahun != 0 && req.Tahun != tahun {
+			return req, fmt.Errorf("tahun di query dan body berbeda")
+		}
+		req.Tahun = tahun
+	}
+
+	if req.Tahun == 0 {
+		return req, fmt.Errorf("params tahun is required, misal: ?tematikIds=1,2&tahun=2025")
+	}
+	if len(req.TematikIds) == 0 {
+		return req, fmt.Errorf("params tematikIds is required, misal: ?tematikIds=1,2&tahun=2025")
+	}
+
+	// buang id ganda, urutan tetap
+	seen := make(map[int]bool)
+	ids := req.TematikIds[:0]
+	for _, id := range req.TematikIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	req.TematikIds = ids
+
+	if len(req.TematikIds) > cfg.Batch.MaxTematik {
+		return req, fmt.Errorf("maksimal %d tematik per batch", cfg.Batch.MaxTematik)
+	}
+	return req, nil
+}
+
+func cascadingBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed, pakai GET atau POST")
+		return
+	}
+
+	req, err := parseBatchRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !tahunAllowed(r.Context(), req.Tahun) {
+		writeError(w, http.StatusForbidden, "akses ditolak: tahun di luar scope")
+		return
+	}
+
+	opts, err := parseBuildOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var fields FieldSet
+	if v := r.URL.Query().Get("fields"); v != "" {
+		fields, err = parseFieldSet(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	kodeOpd := restrictedOpd(r.Context())
+	results := make([]BatchResult, len(req.TematikIds))
+
+	forEachTematik(req.TematikIds, func(idx, tematikId int) {
+		results[idx] = buildBatchResult(r.Context(), tematikId, req.Tahun, opts, fields, kodeOpd)
+	})
+
+	response := CascadingBatch{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Laporan Cascading Pemda Tahun %d", req.Tahun),
+		Data:    results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// forEachTematik menjalankan fn untuk tiap tematik dengan paling banyak
+// cfg.Batch.Workers goroutine, dan menunggu semuanya selesai.
+func forEachTematik(tematikIds []int, fn func(idx, tematikId int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(cfg.Batch.Workers, len(tematikIds)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				fn(idx, tematikIds[idx])
+			}
+		}()
+	}
+	for idx := range tematikIds {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// buildBatchResult membangun satu tematik dengan mengambil slot build sendiri,
+// sehingga batch besar tetap tunduk pada batas build bersamaan. Tematik yang
+// tidak ada di tahun itu berstatus 200 dengan data kosong, sama seperti
+// /laporan/cascading_pemda.
+func buildBatchResult(ctx context.Context, tematikId, tahun int, opts BuildOptions, fields FieldSet, kodeOpd string) BatchResult {
+	result := BatchResult{TematikId: tematikId}
+
+	if !builds.acquire(ctx) {
+		result.Status = http.StatusTooManyRequests
+		result.Error = "server sedang sibuk membangun laporan, coba lagi nanti"
+		return result
+	}
+	defer builds.release()
+
+	list, err := buildTematik(ctx, tematikId, tahun, opts)
+	if err != nil {
+		result.Status = http.StatusInternalServerError
+		result.Error = err.Error()
+		return result
+	}
+	if kodeOpd != "" {
+		if list, _, err = restrictTreeToOpd(list, kodeOpd); err != nil {
+			result.Status = http.StatusInternalServerError
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	result.Status = http.StatusOK
+	if rupiah := formatPaguFrom(ctx); fields != nil || rupiah {
+		result.Data = projector{fields: fields, rupiah: rupiah}.project(list)
+	} else {
+		result.Data = list
+	}
+	return result
+}
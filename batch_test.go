@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseBatchRequest(t *testing.T) {
+	saved := cfg.Batch
+	defer func() { cfg.Batch = saved }()
+	cfg.Batch = BatchConfig{MaxTematik: 3, Workers: 2}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		ids     []int
+		tahun   int
+		wantErr string
+	}{
+		{"get", "GET", "/batch?tematikIds=3,1,3&tahun=2025", "", []int{3, 1}, 2025, ""},
+		{"post", "POST", "/batch", `{"tematik_ids":[2,2,5],"tahun":2025}`, []int{2, 5}, 2025, ""},
+		{"post tahun di query", "POST", "/batch?tahun=2025", `{"tematik_ids":[1]}`, []int{1}, 2025, ""},
+		{"tahun query dan body beda", "POST", "/batch?tahun=2024", `{"tematik_ids":[1],"tahun":2025}`, nil, 0, "berbeda"},
+		{"body rusak", "POST", "/batch", `{"tematik_ids":`, nil, 0, "body tidak valid"},
+		{"id bukan angka", "GET", "/batch?tematikIds=1,x&tahun=2025", "", nil, 0, "invalid tematikIds"},
+		{"tanpa tahun", "GET", "/batch?tematikIds=1", "", nil, 0, "tahun is required"},
+		{"tanpa id", "GET", "/batch?tahun=2025", "", nil, 0, "tematikIds is required"},
+		// batas dihitung setelah id ganda dibuang
+		{"lewat batas", "GET", "/batch?tematikIds=1,2,3,4&tahun=2025", "", nil, 0, "maksimal 3"},
+		{"ganda tidak dihitung", "GET", "/batch?tematikIds=1,2,3,3,1&tahun=2025", "", []int{1, 2, 3}, 2025, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		req, err := parseBatchRequest(httptest.NewRecorder(), r)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want mengandung %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(req.TematikIds, tt.ids) || req.Tahun != tt.tahun {
+			t.Errorf("%s: request = %+v, want ids %v tahun %d", tt.name, req, tt.ids, tt.tahun)
+		}
+	}
+}
+
+func TestForEachTematikBoundsWorkers(t *testing.T) {
+	saved := cfg.Batch
+	defer func() { cfg.Batch = saved }()
+	cfg.Batch.Workers = 3
+
+	ids := []int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	var running, peak atomic.Int64
+	var mu sync.Mutex
+	seen := make(map[int]int)
+
+	forEachTematik(ids, func(idx, tematikId int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+
+		mu.Lock()
+		seen[idx] = tematikId
+		mu.Unlock()
+	})
+
+	if peak.Load() > 3 {
+		t.Errorf("goroutine bersamaan = %d, want paling banyak 3", peak.Load())
+	}
+	if len(seen) != len(ids) {
+		t.Fatalf("tematik diproses = %d, want %d", len(seen), len(ids))
+	}
+	for idx, id := range ids {
+		if seen[idx] != id {
+			t.Errorf("index %d diproses dengan tematik %d, want %d", idx, seen[idx], id)
+		}
+	}
+}
+
+func TestCascadingBatchIsolatesErrors(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		if !strings.Contains(query, "jenis_pohon = 'Tematik'") {
+			return fakeResult{}
+		}
+		switch args[1] {
+		case int64(1):
+			return fakeAnswer([]driver.Value{int64(1), int64(2025), "Tematik Satu", "", "Tematik", int64(0), "", "disetujui"})
+		case int64(2):
+			return fakeResult{err: errors.New("tabel rusak")}
+		}
+		return fakeResult{}
+	})
+
+	r := httptest.NewRequest("GET", "/laporan/cascading_pemda/batch?tematikIds=1,2,3&tahun=2025", nil)
+	w := httptest.NewRecorder()
+	cascadingBatchHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var got struct {
+		Data []struct {
+			TematikId int                 `json:"tematik_id"`
+			Status    int                 `json:"status"`
+			Error     string              `json:"error"`
+			Data      []PohonKinerjaPemda `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Data) != 3 {
+		t.Fatalf("hasil = %+v, want 3 tematik", got.Data)
+	}
+
+	// urutan hasil mengikuti urutan id, gagal di satu id tidak menular
+	one, two, three := got.Data[0], got.Data[1], got.Data[2]
+	if one.TematikId != 1 || one.Status != http.StatusOK || len(one.Data) != 1 || one.Data[0].NamaPohon != "Tematik Satu" {
+		t.Errorf("tematik 1 = %+v", one)
+	}
+	if two.TematikId != 2 || two.Status != http.StatusInternalServerError || !strings.Contains(two.Error, "tabel rusak") {
+		t.Errorf("tematik 2 = %+v, want 500 dengan error", two)
+	}
+	// tematik yang tidak ada sama dengan /laporan/cascading_pemda: 200 data kosong
+	if three.TematikId != 3 || three.Status != http.StatusOK || three.Error != "" || len(three.Data) != 0 {
+		t.Errorf("tematik 3 = %+v, want 200 tanpa data", three)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache menyimpan data master (urusan, bidang urusan, program, kegiatan)
+// yang jarang berubah supaya tidak di-query ulang di setiap node dan setiap
+// tematik. Cache nil selalu miss. Kode yang tidak ditemukan di master tidak
+// disimpan, supaya master yang baru diisi langsung terbaca tanpa menunggu ttl.
+type ttlCache[V any] struct {
+	ttl time.Duration
+
+	mu    sync.RWMutex
+	items map[string]cacheItem[V]
+}
+
+type cacheItem[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ttlCache[V]{ttl: ttl, items: make(map[string]cacheItem[V])}
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.RLock()
+	item, ok := c.items[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(item.expires) {
+		return zero, false
+	}
+	return item.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.items[key] = cacheItem[V]{value: value, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+}
+
+var (
+	urusanCache       *ttlCache[Urusan]
+	bidangUrusanCache *ttlCache[BidangUrusan]
+	programCache      *ttlCache[Program]
+	kegiatanCache     *ttlCache[Kegiatan]
+)
+
+func initMasterCache(ttl time.Duration) {
+	urusanCache = newTTLCache[Urusan](ttl)
+	bidangUrusanCache = newTTLCache[BidangUrusan](ttl)
+	programCache = newTTLCache[Program](ttl)
+	kegiatanCache = newTTLCache[Kegiatan](ttl)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMasterCacheSkipsMisses(t *testing.T) {
+	var queries atomic.Int64
+	var filled atomic.Bool
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		queries.Add(1)
+		if !filled.Load() {
+			return fakeResult{}
+		}
+		return fakeAnswer([]driver.Value{"1.01.01", "Program Penunjang"})
+	})
+	initMasterCache(time.Hour)
+
+	ctx := context.Background()
+	prog, err := getProgramFromKegiatan(ctx, "1.01.01.2.01")
+	if err != nil || prog.KodeProgram != "" {
+		t.Fatalf("program belum ada = %+v, %v", prog, err)
+	}
+
+	// master baru diisi: miss sebelumnya tidak boleh tersimpan
+	filled.Store(true)
+	prog, err = getProgramFromKegiatan(ctx, "1.01.01.2.01")
+	if err != nil || prog.NamaProgram != "Program Penunjang" {
+		t.Fatalf("program = %+v, %v, want terbaca setelah master diisi", prog, err)
+	}
+
+	// hit berikutnya dari cache
+	before := queries.Load()
+	if _, err := getProgramFromKegiatan(ctx, "1.01.01.2.02"); err != nil {
+		t.Fatal(err)
+	}
+	if queries.Load() != before {
+		t.Error("program yang sudah ditemukan harus diambil dari cache")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// status capaian per indikator
+const (
+	capaianOK                  = "ok"
+	capaianTanpaTarget         = "tanpa_target"
+	capaianTargetTidakValid    = "target_tidak_valid"
+	capaianTargetNol           = "target_nol"
+	capaianTanpaRealisasi      = "tanpa_realisasi"
+	capaianRealisasiTidakValid = "realisasi_tidak_valid"
+)
+
+// arah indikator
+const (
+	arahNaik  = "naik"
+	arahTurun = "turun"
+)
+
+// mysql: table doesn't exist
+const mysqlErrNoSuchTable = 1146
+
+// realisasiIndikator adalah satu baris cfg.RealisasiTable.
+type realisasiIndikator struct {
+	Nilai string
+	Arah  string
+}
+
+// parseArah membaca kolom arah, selain nilai "turun" dianggap naik.
+func parseArah(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "turun", "menurun", "negatif":
+		return arahTurun
+	}
+	return arahNaik
+}
+
+type IndikatorCapaian struct {
+	IdIndikator  string        `json:"id_indikator"`
+	Indikator    string        `json:"indikator"`
+	Satuan       string        `json:"satuan"`
+	Target       string        `json:"target"`
+	NilaiTarget  *float64      `json:"nilai_target"`
+	Realisasi    *ParsedNumber `json:"realisasi,omitempty"`
+	RealisasiRaw string        `json:"realisasi_raw,omitempty"`
+	// Arah naik: capaian = realisasi / target * 100. Arah turun (makin
+	// kecil makin baik): capaian = (2 * target - realisasi) / target * 100.
+	Arah    string   `json:"arah"`
+	Capaian *float64 `json:"capaian"`
+	Status  string   `json:"status"`
+}
+
+type NodeCapaian struct {
+	IdPohon    int        `json:"id_pohon"`
+	NamaPohon  string     `json:"nama_pohon"`
+	JenisPohon JenisPohon `json:"jenis_pohon"`
+	Path       []PathNode `json:"path"`
+	// Capaian node adalah rata-rata capaian indikator yang bisa dihitung
+	Capaian    *float64           `json:"capaian"`
+	Indikators []IndikatorCapaian `json:"indikator"`
+}
+
+type CapaianReport struct {
+	Tahun   int      `json:"tahun"`
+	Tematik PathNode `json:"tematik"`
+	// RealisasiTersedia false bila tabel realisasi belum ada atau dimatikan,
+	// angka target tetap di-parse tetapi capaian kosong
+	RealisasiTersedia bool          `json:"realisasi_tersedia"`
+	Capaian           *float64      `json:"capaian"`
+	Nodes             []NodeCapaian `json:"nodes"`
+}
+
+type CapaianResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    CapaianReport `json:"data"`
+}
+
+// getRealisasi mengambil realisasi indikator untuk tahun dari
+// cfg.RealisasiTable. ok=false bila fitur dimatikan atau tabelnya tidak ada.
+func getRealisasi(ctx context.Context, indikatorIds []string, tahun int) (map[string]realisasiIndikator, bool, error) {
+	if cfg.RealisasiTable == "" {
+		return nil, false, nil
+	}
+	result := make(map[string]realisasiIndikator)
+	if len(indikatorIds) == 0 {
+		return result, true, nil
+	}
+
+	args := []any{tahun}
+	for _, id := range indikatorIds {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(indikatorIds)), ",")
+
+	// nama tabel dan kolom sudah divalidasi di Config.Validate
+	arahCol := "NULL"
+	if cfg.RealisasiArahColumn != "" {
+		arahCol = cfg.RealisasiArahColumn
+	}
+	rows, err := queryRetry(ctx, `SELECT indikator_id, realisasi, `+arahCol+` FROM `+cfg.RealisasiTable+`
+		WHERE tahun = ? AND indikator_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		var myErr *mysql.MySQLError
+		if errors.As(err, &myErr) && myErr.Number == mysqlErrNoSuchTable {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("query realisasi error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var realisasi, arah sql.NullString
+		if err := rows.Scan(&id, &realisasi, &arah); err != nil {
+			return nil, false, fmt.Errorf("scan realisasi error: %w", err)
+		}
+		if realisasi.Valid {
+			result[id] = realisasiIndikator{Nilai: realisasi.String, Arah: parseArah(arah.String)}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("rows error: %w", err)
+	}
+	return result, true, nil
+}
+
+// targetTahun memilih target untuk tahun laporan, target tanpa tahun
+// dipakai bila tidak ada yang cocok.
+func targetTahun(ind IndikatorPohon, tahun int) (TargetIndikator, bool) {
+	var fallback *TargetIndikator
+	for i, t := range ind.Target {
+		if t.Tahun == tahun {
+			return t, true
+		}
+		if t.Tahun == 0 && fallback == nil {
+			fallback = &ind.Target[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return TargetIndikator{}, false
+}
+
+func hitungCapaian(ind IndikatorPohon, tahun int, realisasi map[string]realisasiIndikator, tersedia bool) IndikatorCapaian {
+	c := IndikatorCapaian{IdIndikator: ind.IdIndikator, Indikator: ind.Indikator, Arah: arahNaik}
+
+	target, ok := targetTahun(ind, tahun)
+	if !ok {
+		c.Status = capaianTanpaTarget
+		return c
+	}
+	c.Target = target.Target
+	c.Satuan = target.Satuan
+	c.NilaiTarget = target.Nilai
+
+	switch {
+	case target.Nilai == nil:
+		c.Status = capaianTargetTidakValid
+		return c
+	case !tersedia:
+		c.Status = capaianTanpaRealisasi
+		return c
+	}
+
+	row, ok := realisasi[ind.IdIndikator]
+	if !ok {
+		c.Status = capaianTanpaRealisasi
+		return c
+	}
+	parsed := parseAngka(row.Nilai, target.Satuan)
+	c.Realisasi = &parsed
+	c.RealisasiRaw = row.Nilai
+	c.Arah = row.Arah
+
+	switch {
+	case parsed.Nilai == nil:
+		c.Status = capaianRealisasiTidakValid
+	case *target.Nilai == 0:
+		c.Status = capaianTargetNol
+	default:
+		t, nilai := *target.Nilai, *parsed.Nilai
+		capaian := nilai / t * 100
+		if c.Arah == arahTurun {
+			capaian = (2*t - nilai) / t * 100
+		}
+		c.Capaian = &capaian
+		c.Status = capaianOK
+	}
+	return c
+}
+
+func rataRata(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	avg := sum / float64(len(values))
+	return &avg
+}
+
+func collectIndikatorIds(nodes []PohonKinerjaPemda, ids []string) []string {
+	for _, n := range nodes {
+		for _, ind := range n.Indikators {
+			ids = append(ids, ind.IdIndikator)
+		}
+		ids = collectIndikatorIds(n.Childs, ids)
+	}
+	return ids
+}
+
+// collectCapaian menghitung capaian tiap node pre-order. all menampung
+// semua capaian indikator untuk rata-rata Tematik.
+func collectCapaian(nodes []PohonKinerjaPemda, path []PathNode, tahun int, realisasi map[string]realisasiIndikator, tersedia bool, out []NodeCapaian, all *[]float64) []NodeCapaian {
+	for _, n := range nodes {
+		nodePath := append(path[:len(path):len(path)], PathNode{IdPohon: n.IdPohon, NamaPohon: n.NamaPohon, JenisPohon: n.JenisPohon})
+
+		node := NodeCapaian{IdPohon: n.IdPohon, NamaPohon: n.NamaPohon, JenisPohon: n.JenisPohon, Path: nodePath}
+		var values []float64
+		for _, ind := range n.Indikators {
+			c := hitungCapaian(ind, tahun, realisasi, tersedia)
+			if c.Capaian != nil {
+				values = append(values, *c.Capaian)
+			}
+			node.Indikators = append(node.Indikators, c)
+		}
+		node.Capaian = rataRata(values)
+		*all = append(*all, values...)
+
+		out = append(out, node)
+		out = collectCapaian(n.Childs, nodePath, tahun, realisasi, tersedia, out, all)
+	}
+	return out
+}
+
+func capaianHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tematikId, tahun, err := tematikTahunParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts := optionsWithout(partRekin, partTagging, partSasaran, partTujuan, partProgram, partBidangUrusan, partUrusan, partKegiatan, partSubkegiatan)
+	list, err := buildTematik(ctx, tematikId, tahun, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(list) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("tematik %d tidak ditemukan untuk tahun %d", tematikId, tahun))
+		return
+	}
+	if kodeOpd := restrictedOpd(r.Context()); kodeOpd != "" {
+		if list, _, err = restrictTreeToOpd(list, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	realisasi, tersedia, err := getRealisasi(ctx, collectIndikatorIds(list, nil), tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	report := CapaianReport{Tahun: tahun, RealisasiTersedia: tersedia}
+	if len(list) > 0 {
+		report.Tematik = PathNode{IdPohon: list[0].IdPohon, NamaPohon: list[0].NamaPohon, JenisPohon: list[0].JenisPohon}
+	}
+	var all []float64
+	report.Nodes = collectCapaian(list, nil, tahun, realisasi, tersedia, nil, &all)
+	report.Capaian = rataRata(all)
+
+	writeLaporan(w, r, CapaianResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Capaian Indikator Tahun %d", tahun),
+		Data:    report,
+	})
+}
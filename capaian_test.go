@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func targetAngka(tahun int, raw, satuan string) TargetIndikator {
+	parsed := parseAngka(raw, satuan)
+	return TargetIndikator{Target: raw, Satuan: satuan, Tahun: tahun, Nilai: parsed.Nilai,
+		Persen: parsed.Persen, Unit: parsed.Unit, ParseStatus: parsed.Status}
+}
+
+func TestHitungCapaian(t *testing.T) {
+	tests := []struct {
+		name      string
+		targets   []TargetIndikator
+		realisasi *realisasiIndikator
+		tersedia  bool
+		status    string
+		capaian   float64
+	}{
+		{"naik", []TargetIndikator{targetAngka(2025, "80", "%")}, &realisasiIndikator{Nilai: "60", Arah: arahNaik}, true, capaianOK, 75},
+		// makin kecil makin baik: (2*20 - 15) / 20 * 100
+		{"turun", []TargetIndikator{targetAngka(2025, "20", "%")}, &realisasiIndikator{Nilai: "15", Arah: arahTurun}, true, capaianOK, 125},
+		{"target nol", []TargetIndikator{targetAngka(2025, "0", "kasus")}, &realisasiIndikator{Nilai: "3", Arah: arahNaik}, true, capaianTargetNol, 0},
+		{"tanpa target", nil, &realisasiIndikator{Nilai: "3", Arah: arahNaik}, true, capaianTanpaTarget, 0},
+		{"target tahun lain", []TargetIndikator{targetAngka(2024, "10", "")}, &realisasiIndikator{Nilai: "3"}, true, capaianTanpaTarget, 0},
+		{"target tidak valid", []TargetIndikator{targetAngka(2025, "meningkat", "")}, &realisasiIndikator{Nilai: "3"}, true, capaianTargetTidakValid, 0},
+		{"realisasi tidak tersedia", []TargetIndikator{targetAngka(2025, "10", "")}, nil, false, capaianTanpaRealisasi, 0},
+		{"realisasi kosong", []TargetIndikator{targetAngka(2025, "10", "")}, nil, true, capaianTanpaRealisasi, 0},
+		{"realisasi tidak valid", []TargetIndikator{targetAngka(2025, "10", "")}, &realisasiIndikator{Nilai: "10-12", Arah: arahNaik}, true, capaianRealisasiTidakValid, 0},
+		// target tanpa tahun dipakai bila tidak ada target tahun laporan
+		{"target tanpa tahun", []TargetIndikator{targetAngka(2024, "10", ""), targetAngka(0, "50", "")}, &realisasiIndikator{Nilai: "25", Arah: arahNaik}, true, capaianOK, 50},
+		{"target tahun didahulukan", []TargetIndikator{targetAngka(0, "50", ""), targetAngka(2025, "25", "")}, &realisasiIndikator{Nilai: "25", Arah: arahNaik}, true, capaianOK, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ind := IndikatorPohon{IdIndikator: "IND-1", Indikator: "Indikator", Target: tt.targets}
+			realisasi := map[string]realisasiIndikator{}
+			if tt.realisasi != nil {
+				realisasi[ind.IdIndikator] = *tt.realisasi
+			}
+			got := hitungCapaian(ind, 2025, realisasi, tt.tersedia)
+			if got.Status != tt.status {
+				t.Fatalf("status = %s, want %s", got.Status, tt.status)
+			}
+			if tt.status != capaianOK {
+				if got.Capaian != nil {
+					t.Errorf("capaian = %v, want nil", *got.Capaian)
+				}
+				return
+			}
+			if got.Capaian == nil || math.Abs(*got.Capaian-tt.capaian) > 1e-9 {
+				t.Errorf("capaian = %v, want %v", got.Capaian, tt.capaian)
+			}
+		})
+	}
+}
+
+func TestTargetIndikatorPersen(t *testing.T) {
+	tar := targetAngka(2025, "75", "persen")
+	if !tar.Persen || tar.Unit != "persen" {
+		t.Errorf("persen %v unit %q, want true dan persen", tar.Persen, tar.Unit)
+	}
+	tar = targetAngka(2025, "12 dokumen", "")
+	if tar.Persen || tar.Unit != "dokumen" {
+		t.Errorf("persen %v unit %q, want false dan dokumen", tar.Persen, tar.Unit)
+	}
+}
+
+func TestGetRealisasiTableMissing(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{err: &mysql.MySQLError{Number: mysqlErrNoSuchTable, Message: "Table 'realisasi_indikator' doesn't exist"}}
+	})
+	cfg.RealisasiTable = "realisasi_indikator"
+
+	result, tersedia, err := getRealisasi(context.Background(), []string{"IND-1"}, 2025)
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if tersedia || result != nil {
+		t.Errorf("tersedia %v result %v, want false dan nil", tersedia, result)
+	}
+}
+
+func TestGetRealisasiDisabled(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		t.Errorf("query tidak diharapkan: %s", query)
+		return fakeResult{}
+	})
+
+	_, tersedia, err := getRealisasi(context.Background(), []string{"IND-1"}, 2025)
+	if err != nil || tersedia {
+		t.Errorf("tersedia %v err %v, want false dan nil", tersedia, err)
+	}
+}
+
+func TestGetRealisasiArah(t *testing.T) {
+	useFakeDB(t, fakeQueryContains(map[string]fakeResult{
+		"FROM realisasi_indikator": fakeAnswer(
+			[]driver.Value{"IND-1", "15", "Menurun"},
+			[]driver.Value{"IND-2", nil, nil},
+		),
+	}))
+	cfg.RealisasiTable = "realisasi_indikator"
+	cfg.RealisasiArahColumn = "arah"
+
+	result, tersedia, err := getRealisasi(context.Background(), []string{"IND-1", "IND-2"}, 2025)
+	if err != nil || !tersedia {
+		t.Fatalf("tersedia %v err %v", tersedia, err)
+	}
+	if got := result["IND-1"]; got.Nilai != "15" || got.Arah != arahTurun {
+		t.Errorf("IND-1 = %+v, want 15 turun", got)
+	}
+	if _, ok := result["IND-2"]; ok {
+		t.Error("realisasi NULL tidak boleh masuk")
+	}
+}
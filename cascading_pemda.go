@@ -2,7 +2,6 @@ package main
 
 type JenisPohon string
 type Keterangan string
-type Pagu int
 
 type CascadingPemda struct {
 	Status  int                 `json:"status"`
@@ -10,6 +9,13 @@ type CascadingPemda struct {
 	Tematik []PohonKinerjaPemda `json:"data"`
 }
 
+// SparseResponse dipakai bila ?fields= membatasi properti yang dikirim.
+type SparseResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    any    `json:"data"`
+}
+
 type PohonKinerjaPemda struct {
 	IdPohon           int                 `json:"id_pohon"`
 	Parent            int                 `json:"parent"`
@@ -24,13 +30,15 @@ type PohonKinerjaPemda struct {
 	UrusanPokin       []Urusan            `json:"urusan,omitempty"`
 	BidangUrusanPokin []BidangUrusan      `json:"bidang_urusan,omitempty"`
 	ProgramPokin      []Program           `json:"program,omitempty"`
+	KegiatanPokin     []Kegiatan          `json:"kegiatan,omitempty"`
+	SubkegiatanPokin  []Subkegiatan       `json:"subkegiatan,omitempty"`
 	Pagu              Pagu                `json:"pagu"`
 	Tagging           []TaggingPokin      `json:"tagging"`
 	RencanaKinerjas   []RencanaKinerjaAsn `json:"rencana_kinerja,omitempty"`
 
 	Indikators []IndikatorPohon    `json:"indikator,omitempty"`
 	Childs     []PohonKinerjaPemda `json:"childs,omitempty"`
-	Status     string              `json:"-"`
+	Status     string              `json:"status,omitempty"`
 }
 
 type Urusan struct {
@@ -50,20 +58,34 @@ type Program struct {
 }
 
 type Kegiatan struct {
-	KodeKegiatan string `json:"kode_kegiatan"`
-	NamaKegiatan string `json:"nama_kegiatan"`
+	KodeKegiatan      string           `json:"kode_kegiatan"`
+	NamaKegiatan      string           `json:"nama_kegiatan"`
+	IndikatorKegiatan []IndikatorPohon `json:"indikator"`
+	// PaguRekinTerkait: jumlah pagu rekin yang memakai kegiatan ini. Rekin
+	// dengan subkegiatan di beberapa kegiatan terhitung penuh di semuanya,
+	// jadi nilai ini tidak boleh dijumlah antar kegiatan.
+	PaguRekinTerkait Pagu `json:"pagu_rekin_terkait"`
 }
 
 type Subkegiatan struct {
-	KodeSubkegiatan string `json:"kode_subkegiatan"`
-	NamaSubkegiatan string `json:"nama_subkegiatan"`
+	KodeSubkegiatan      string           `json:"kode_subkegiatan"`
+	NamaSubkegiatan      string           `json:"nama_subkegiatan"`
+	IndikatorSubkegiatan []IndikatorPohon `json:"indikator"`
+	// PaguRekinTerkait: jumlah pagu rekin yang memakai subkegiatan ini.
+	// Rincian belanja menempel di rekin, bukan di subkegiatan, sehingga
+	// rekin dengan beberapa subkegiatan terhitung penuh di setiap
+	// subkegiatannya. Jangan dijumlah antar subkegiatan, pakai pagu node.
+	PaguRekinTerkait Pagu `json:"pagu_rekin_terkait"`
 }
 
 type RencanaKinerjaAsn struct {
-	IdRekin              string           `json:"id_rencana_kinerja"`
-	RencanaKinerja       string           `json:"nama_rencana_kinerja"`
-	NamaPelaksana        string           `json:"nama_pegawai"`
-	NIPPelaksana         string           `json:"pegawai_id"`
+	IdRekin        string `json:"id_rencana_kinerja"`
+	RencanaKinerja string `json:"nama_rencana_kinerja"`
+	NamaPelaksana  string `json:"nama_pegawai"`
+	NIPPelaksana   string `json:"pegawai_id"`
+	// KodeOpd adalah OPD pemilik pokin rekin (pokin OPD hasil clone node
+	// pemda), bisa berbeda dengan kode_opd node pemdanya.
+	KodeOpd              string           `json:"kode_opd,omitempty"`
 	KodeKegiatan         string           `json:"kode_kegiatan"`
 	NamaKegiatan         string           `json:"nama_kegiatan"`
 	IndikatorKegiatan    []IndikatorPohon `json:"indikator_kegiatan"`
@@ -87,6 +109,12 @@ type TargetIndikator struct {
 	Target      string `json:"target"`
 	Satuan      string `json:"satuan"`
 	Tahun       int    `json:"tahun,omitempty"`
+	// Nilai adalah Target dalam bentuk angka, null bila ParseStatus bukan ok.
+	// Persen dan Unit berasal dari teks target atau Satuan, lihat parseAngka.
+	Nilai       *float64 `json:"nilai"`
+	Persen      bool     `json:"persen"`
+	Unit        string   `json:"unit,omitempty"`
+	ParseStatus string   `json:"parse_status"`
 }
 
 type TaggingPokin struct {
@@ -95,6 +123,9 @@ type TaggingPokin struct {
 	NamaTagging       string `json:"nama_tagging"`
 	KeteranganTagging string `json:"keterangan_tagging"`
 	CloneFrom         int    `json:"clone_from"`
+	// InheritedFrom berisi id_pokin leluhur pemilik tagging bila tagging ini
+	// diturunkan, kosong untuk tagging milik node sendiri.
+	InheritedFrom int `json:"inherited_from,omitempty"`
 }
 
 type TujuanPemda struct {
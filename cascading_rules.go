@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sumber data agregat. Tanpa awalan berarti data node itu sendiri,
+// awalan childs. berarti gabungan data semua anak langsungnya.
+const (
+	sourcePohon             = "pohon"
+	sourceRekin             = "rekin"
+	sourceProgram           = "program"
+	sourceBidangUrusan      = "bidang_urusan"
+	sourceChildRekin        = "childs.rekin"
+	sourceChildProgram      = "childs.program"
+	sourceChildBidangUrusan = "childs.bidang_urusan"
+)
+
+// CascadingRule menentukan satu agregat yang dihitung untuk node dengan
+// jenis pohon tertentu. Aturan untuk jenis yang sama dievaluasi berurutan,
+// jadi agregat yang memakai data node sendiri harus ditulis setelah
+// aturan yang mengisinya.
+type CascadingRule struct {
+	JenisPohon JenisPohon `json:"jenis_pohon" yaml:"jenis_pohon"`
+	// Aggregate: program, bidang_urusan, urusan, sasaran, kegiatan atau subkegiatan
+	Aggregate string   `json:"aggregate" yaml:"aggregate"`
+	From      []string `json:"from" yaml:"from"`
+	// RequireDisetujui: agregat hanya dihitung bila status node disetujui
+	RequireDisetujui bool `json:"require_disetujui" yaml:"require_disetujui"`
+}
+
+// defaultCascadingRules sama dengan perilaku cascading sebelum ada aturan.
+func defaultCascadingRules() []CascadingRule {
+	return []CascadingRule{
+		{JenisPohon: "Tematik", Aggregate: partUrusan, From: []string{sourceChildBidangUrusan}},
+		{JenisPohon: "Sub Tematik", Aggregate: partBidangUrusan, From: []string{sourceChildBidangUrusan}},
+		{JenisPohon: "Sub Tematik", Aggregate: partSasaran, From: []string{sourcePohon}},
+		{JenisPohon: "Sub Sub Tematik", Aggregate: partSasaran, From: []string{sourcePohon}},
+		{JenisPohon: "Strategic Pemda", Aggregate: partBidangUrusan, From: []string{sourceChildProgram}, RequireDisetujui: true},
+		{JenisPohon: "Tactical Pemda", Aggregate: partProgram, From: []string{sourceChildRekin}, RequireDisetujui: true},
+		{JenisPohon: "Tactical Pemda", Aggregate: partKegiatan, From: []string{sourceRekin, sourceChildRekin}},
+		{JenisPohon: "Tactical Pemda", Aggregate: partSubkegiatan, From: []string{sourceRekin, sourceChildRekin}},
+		{JenisPohon: "Operational Pemda", Aggregate: partKegiatan, From: []string{sourceRekin}},
+		{JenisPohon: "Operational Pemda", Aggregate: partSubkegiatan, From: []string{sourceRekin}},
+	}
+}
+
+// ruleInput adalah data dari sumber-sumber sebuah aturan.
+type ruleInput struct {
+	rekins        []RencanaKinerjaAsn
+	programs      []Program
+	bidangUrusans []BidangUrusan
+}
+
+type aggregator struct {
+	sources []string
+	apply   func(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, tahun int, opts BuildOptions) error
+}
+
+// aggregators dikunci dengan nama bagian di options.go, sehingga
+// ?include= dan ?exclude= tetap menentukan agregat mana yang dihitung.
+var aggregators = map[string]aggregator{
+	partProgram: {
+		sources: []string{sourceRekin, sourceChildRekin},
+		apply:   aggregateProgram,
+	},
+	partBidangUrusan: {
+		sources: []string{sourceProgram, sourceBidangUrusan, sourceChildProgram, sourceChildBidangUrusan},
+		apply:   aggregateBidangUrusan,
+	},
+	partUrusan: {
+		sources: []string{sourceBidangUrusan, sourceChildBidangUrusan},
+		apply:   aggregateUrusan,
+	},
+	partSasaran: {
+		sources: []string{sourcePohon},
+		apply: func(ctx context.Context, pt *PohonKinerjaPemda, _ ruleInput, _ int, _ BuildOptions) error {
+			sasaranPemdas, err := getSasaranPemda(ctx, pt.IdPohon)
+			if err != nil {
+				return err
+			}
+			pt.SasaranPemda = sasaranPemdas
+			return nil
+		},
+	},
+	partKegiatan: {
+		sources: []string{sourceRekin, sourceChildRekin},
+		apply: func(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, tahun int, opts BuildOptions) error {
+			kegiatans, err := rollupKegiatan(ctx, in.rekins, tahun, opts)
+			if err != nil {
+				return err
+			}
+			pt.KegiatanPokin = kegiatans
+			return nil
+		},
+	},
+	partSubkegiatan: {
+		sources: []string{sourceRekin, sourceChildRekin},
+		apply: func(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, tahun int, opts BuildOptions) error {
+			subkegiatans, err := rollupSubkegiatan(ctx, in.rekins, tahun, opts)
+			if err != nil {
+				return err
+			}
+			pt.SubkegiatanPokin = subkegiatans
+			return nil
+		},
+	},
+}
+
+// applyCascadingRules menghitung agregat node sesuai cfg.CascadingRules.
+// Dipanggil setelah anak-anak node selesai dibangun.
+func applyCascadingRules(ctx context.Context, pt *PohonKinerjaPemda, tahun int, opts BuildOptions) error {
+	for _, rule := range cfg.CascadingRules {
+		if rule.JenisPohon != pt.JenisPohon {
+			continue
+		}
+		if rule.RequireDisetujui && pt.Status != statusDisetujui {
+			continue
+		}
+		if !opts.loads(rule.Aggregate) {
+			continue
+		}
+		agg := aggregators[rule.Aggregate]
+		if err := agg.apply(ctx, pt, collectRuleInput(*pt, rule.From), tahun, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectRuleInput(pt PohonKinerjaPemda, from []string) ruleInput {
+	var in ruleInput
+	for _, src := range from {
+		switch src {
+		case sourceRekin:
+			in.rekins = append(in.rekins, pt.RencanaKinerjas...)
+		case sourceProgram:
+			in.programs = append(in.programs, pt.ProgramPokin...)
+		case sourceBidangUrusan:
+			in.bidangUrusans = append(in.bidangUrusans, pt.BidangUrusanPokin...)
+		case sourceChildRekin:
+			for _, child := range pt.Childs {
+				in.rekins = append(in.rekins, child.RencanaKinerjas...)
+			}
+		case sourceChildProgram:
+			for _, child := range pt.Childs {
+				in.programs = append(in.programs, child.ProgramPokin...)
+			}
+		case sourceChildBidangUrusan:
+			for _, child := range pt.Childs {
+				in.bidangUrusans = append(in.bidangUrusans, child.BidangUrusanPokin...)
+			}
+		}
+	}
+	return in
+}
+
+func aggregateProgram(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, tahun int, opts BuildOptions) error {
+	var programs []Program
+	seen := make(map[string]struct{})
+
+	for _, kegiatan := range in.rekins {
+		if kegiatan.KodeKegiatan == "" {
+			// skip kalau kode kosong
+			continue
+		}
+
+		programPokin, err := getProgramFromKegiatan(ctx, kegiatan.KodeKegiatan)
+		if err != nil {
+			// bisa log atau teruskan, sesuai kebutuhan
+			// log.Printf("Program tidak ditemukan untuk kode %s: %v", kegiatan.KodeKegiatan, err)
+			continue
+		}
+
+		if _, ok := seen[programPokin.KodeProgram]; !ok {
+			seen[programPokin.KodeProgram] = struct{}{}
+
+			// get indikator program
+			if opts.loads(partIndikator) && opts.shows(partProgram) {
+				indList, err := getIndikatorsPKS(ctx, programPokin.KodeProgram, tahun, opts.loads(partTarget))
+				if err != nil {
+					return fmt.Errorf("Indikator program error")
+				}
+				programPokin.IndikatorProgram = indList
+			}
+
+			programs = append(programs, programPokin)
+		}
+	}
+
+	pt.ProgramPokin = programs
+	return nil
+}
+
+// aggregateBidangUrusan mengambil bidang urusan dari kode program, lalu
+// menggabungkan bidang urusan yang sudah jadi, tanpa duplikat.
+func aggregateBidangUrusan(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, _ int, _ BuildOptions) error {
+	var bidangUrusans []BidangUrusan
+	seen := make(map[string]bool)
+	add := func(bidangUrusanPokin BidangUrusan) {
+		if !seen[bidangUrusanPokin.KodeBidangUrusan] {
+			seen[bidangUrusanPokin.KodeBidangUrusan] = true
+			bidangUrusans = append(bidangUrusans, bidangUrusanPokin)
+		}
+	}
+
+	for _, program := range in.programs {
+		bidangUrusanPokin, err := getBidangUrusan(ctx, program.KodeProgram)
+		if err != nil {
+			return fmt.Errorf("Bidang Urusan tidak ditermukan")
+		}
+		add(bidangUrusanPokin)
+	}
+	for _, bidangUrusanPokin := range in.bidangUrusans {
+		add(bidangUrusanPokin)
+	}
+
+	pt.BidangUrusanPokin = bidangUrusans
+	return nil
+}
+
+func aggregateUrusan(ctx context.Context, pt *PohonKinerjaPemda, in ruleInput, _ int, _ BuildOptions) error {
+	var urusans []Urusan
+	seen := make(map[string]bool)
+
+	for _, bidangUrusan := range in.bidangUrusans {
+		urusanPokin, err := getUrusan(ctx, bidangUrusan.KodeBidangUrusan)
+		if err != nil {
+			return err
+		}
+		if !seen[urusanPokin.KodeUrusan] {
+			seen[urusanPokin.KodeUrusan] = true
+			urusans = append(urusans, urusanPokin)
+		}
+	}
+
+	pt.UrusanPokin = urusans
+	return nil
+}
+
+// validateCascadingRules memastikan setiap aturan memakai agregat dan
+// sumber yang dikenal, supaya salah ketik ketahuan saat start.
+func validateCascadingRules(rules []CascadingRule) error {
+	var errs []error
+	for i, rule := range rules {
+		if strings.TrimSpace(string(rule.JenisPohon)) == "" {
+			errs = append(errs, fmt.Errorf("cascading_rules[%d]: jenis_pohon wajib diisi", i))
+		}
+		agg, ok := aggregators[rule.Aggregate]
+		if !ok {
+			errs = append(errs, fmt.Errorf("cascading_rules[%d]: aggregate %q tidak dikenal", i, rule.Aggregate))
+			continue
+		}
+		if len(rule.From) == 0 {
+			errs = append(errs, fmt.Errorf("cascading_rules[%d]: from wajib diisi, pilihan: %s", i, strings.Join(agg.sources, ",")))
+		}
+		for _, src := range rule.From {
+			if !slices.Contains(agg.sources, src) {
+				errs = append(errs, fmt.Errorf("cascading_rules[%d]: sumber %q tidak bisa dipakai untuk %s, pilihan: %s",
+					i, src, rule.Aggregate, strings.Join(agg.sources, ",")))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
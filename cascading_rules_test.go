@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateCascadingRules(t *testing.T) {
+	if err := validateCascadingRules(defaultCascadingRules()); err != nil {
+		t.Fatalf("aturan bawaan tidak valid: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rule CascadingRule
+		want string
+	}{
+		{"jenis kosong", CascadingRule{Aggregate: partProgram, From: []string{sourceRekin}}, "jenis_pohon wajib diisi"},
+		{"aggregate salah", CascadingRule{JenisPohon: "Tactical Pemda", Aggregate: "anggaran", From: []string{sourceRekin}}, `aggregate "anggaran" tidak dikenal`},
+		{"from kosong", CascadingRule{JenisPohon: "Tactical Pemda", Aggregate: partProgram}, "from wajib diisi"},
+		{"sumber salah", CascadingRule{JenisPohon: "Tematik", Aggregate: partUrusan, From: []string{sourceRekin}}, `sumber "rekin" tidak bisa dipakai untuk urusan`},
+	}
+	for _, tt := range tests {
+		err := validateCascadingRules([]CascadingRule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want mengandung %q", tt.name, err, tt.want)
+		}
+	}
+
+	// semua kesalahan dilaporkan sekaligus
+	err := validateCascadingRules([]CascadingRule{tests[0].rule, tests[1].rule})
+	if err == nil || !strings.Contains(err.Error(), "cascading_rules[0]") || !strings.Contains(err.Error(), "cascading_rules[1]") {
+		t.Errorf("error = %v, want kedua aturan disebut", err)
+	}
+}
+
+func TestCollectRuleInput(t *testing.T) {
+	pt := PohonKinerjaPemda{
+		RencanaKinerjas:   []RencanaKinerjaAsn{{IdRekin: "R0"}},
+		BidangUrusanPokin: []BidangUrusan{{KodeBidangUrusan: "1.01"}},
+		Childs: []PohonKinerjaPemda{
+			{RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R1"}}, ProgramPokin: []Program{{KodeProgram: "1.01.01"}}},
+			{RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R2"}}, BidangUrusanPokin: []BidangUrusan{{KodeBidangUrusan: "1.02"}}},
+		},
+	}
+
+	in := collectRuleInput(pt, []string{sourceRekin, sourceChildRekin})
+	if len(in.rekins) != 3 || in.rekins[0].IdRekin != "R0" || in.rekins[2].IdRekin != "R2" {
+		t.Errorf("rekins = %+v, want R0, R1, R2", in.rekins)
+	}
+	in = collectRuleInput(pt, []string{sourceChildRekin})
+	if len(in.rekins) != 2 {
+		t.Errorf("childs.rekin = %+v, want R1, R2", in.rekins)
+	}
+	in = collectRuleInput(pt, []string{sourceBidangUrusan, sourceChildBidangUrusan, sourceChildProgram})
+	if len(in.bidangUrusans) != 2 || len(in.programs) != 1 {
+		t.Errorf("bidang urusan %+v, program %+v", in.bidangUrusans, in.programs)
+	}
+}
+
+func TestApplyCascadingRulesSkipsUnmatched(t *testing.T) {
+	saved := cfg.CascadingRules
+	defer func() { cfg.CascadingRules = saved }()
+	cfg.CascadingRules = []CascadingRule{
+		{JenisPohon: "Tactical Pemda", Aggregate: partKegiatan, From: []string{sourceRekin}},
+		{JenisPohon: "Tactical Pemda", Aggregate: partSubkegiatan, From: []string{sourceRekin}, RequireDisetujui: true},
+	}
+	opts := defaultBuildOptions()
+	delete(opts.parts, partIndikator)
+
+	pt := PohonKinerjaPemda{
+		JenisPohon: "Tactical Pemda",
+		Status:     "draft",
+		RencanaKinerjas: []RencanaKinerjaAsn{
+			{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 10},
+		},
+	}
+	if err := applyCascadingRules(context.Background(), &pt, 2025, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(pt.KegiatanPokin) != 1 {
+		t.Errorf("kegiatan = %+v, want satu kegiatan", pt.KegiatanPokin)
+	}
+	if pt.SubkegiatanPokin != nil {
+		t.Errorf("subkegiatan harus kosong untuk node belum disetujui, dapat %+v", pt.SubkegiatanPokin)
+	}
+
+	// jenis pohon lain tidak tersentuh
+	other := PohonKinerjaPemda{JenisPohon: "Operational Pemda", RencanaKinerjas: pt.RencanaKinerjas}
+	if err := applyCascadingRules(context.Background(), &other, 2025, opts); err != nil {
+		t.Fatal(err)
+	}
+	if other.KegiatanPokin != nil {
+		t.Errorf("aturan Tactical Pemda tidak boleh berlaku untuk Operational Pemda")
+	}
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration membungkus time.Duration supaya bisa ditulis "10s" / "5m"
+// di file konfigurasi JSON maupun YAML.
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type Config struct {
+	Port   string       `json:"port" yaml:"port"`
+	Server ServerConfig `json:"server" yaml:"server"`
+	DB     DBConfig     `json:"db" yaml:"db"`
+	CORS   CORSConfig   `json:"cors" yaml:"cors"`
+	Auth   AuthConfig   `json:"auth" yaml:"auth"`
+	Limit  LimitConfig  `json:"limit" yaml:"limit"`
+	Batch  BatchConfig  `json:"batch" yaml:"batch"`
+	// MasterCacheTTL: lama data master (urusan s.d. kegiatan) disimpan di memori, 0 mematikan cache
+	MasterCacheTTL Duration `json:"master_cache_ttl" yaml:"master_cache_ttl"`
+	// RealisasiTable: tabel realisasi indikator (indikator_id, tahun, realisasi)
+	// untuk menghitung capaian. Default kosong, yang berarti capaian mati
+	// sampai tabelnya disiapkan.
+	RealisasiTable string `json:"realisasi_table" yaml:"realisasi_table"`
+	// RealisasiArahColumn: kolom opsional di RealisasiTable berisi arah
+	// indikator. Nilai "turun" (atau "menurun", "negatif") berarti makin
+	// kecil realisasi makin baik, kosong berarti semua indikator naik.
+	RealisasiArahColumn string `json:"realisasi_arah_column" yaml:"realisasi_arah_column"`
+	// CascadingRules: agregat per jenis pohon, mengganti seluruh aturan bawaan bila diisi
+	CascadingRules []CascadingRule `json:"cascading_rules" yaml:"cascading_rules"`
+}
+
+type BatchConfig struct {
+	MaxTematik int `json:"max_tematik" yaml:"max_tematik"`
+	Workers    int `json:"workers" yaml:"workers"`
+}
+
+type ServerConfig struct {
+	ReadHeaderTimeout Duration `json:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+}
+
+type DBConfig struct {
+	URL             string   `json:"url" yaml:"url"`
+	MaxOpenConns    int      `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	PingTimeout     Duration `json:"ping_timeout" yaml:"ping_timeout"`
+
+	// backoff untuk koneksi awal, reconnect, dan retry query baca
+	RetryInitialBackoff Duration `json:"retry_initial_backoff" yaml:"retry_initial_backoff"`
+	RetryMaxBackoff     Duration `json:"retry_max_backoff" yaml:"retry_max_backoff"`
+	StartupMaxAttempts  int      `json:"startup_max_attempts" yaml:"startup_max_attempts"`
+	// StartNotReady: server tetap jalan (readiness 503) walau database belum bisa dihubungi
+	StartNotReady       bool     `json:"start_not_ready" yaml:"start_not_ready"`
+	HealthCheckInterval Duration `json:"health_check_interval" yaml:"health_check_interval"`
+	QueryRetries        int      `json:"query_retries" yaml:"query_retries"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins berisi origin persis (https://app.go.id),
+	// wildcard subdomain (https://*.madiunkota.go.id), atau "*" untuk semua.
+	AllowedOrigins   []string `json:"allowed_origins" yaml:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods" yaml:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers" yaml:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers" yaml:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           Duration `json:"max_age" yaml:"max_age"`
+}
+
+type AuthConfig struct {
+	Enabled  bool     `json:"enabled" yaml:"enabled"`
+	Issuer   string   `json:"issuer" yaml:"issuer"`
+	Audience string   `json:"audience" yaml:"audience"`
+	Leeway   Duration `json:"leeway" yaml:"leeway"`
+	Keys     []JWTKey `json:"keys" yaml:"keys"`
+
+	RolesClaim string `json:"roles_claim" yaml:"roles_claim"`
+	OpdClaim   string `json:"opd_claim" yaml:"opd_claim"`
+	// BappedaRoles boleh melihat seluruh cascading, role lain dibatasi ke kode_opd-nya
+	BappedaRoles []string `json:"bappeda_roles" yaml:"bappeda_roles"`
+
+	// API key untuk integrasi antar layanan, dibaca ulang berkala dari file
+	// sehingga key bisa dicabut tanpa restart.
+	APIKeyHeader         string   `json:"api_key_header" yaml:"api_key_header"`
+	APIKeysFile          string   `json:"api_keys_file" yaml:"api_keys_file"`
+	APIKeyReloadInterval Duration `json:"api_key_reload_interval" yaml:"api_key_reload_interval"`
+}
+
+// JWTKey adalah satu kunci verifikasi JWT. Untuk HS* isi Secret,
+// untuk RS* isi PublicKey (PEM) atau PublicKeyFile.
+type JWTKey struct {
+	Kid           string `json:"kid" yaml:"kid"`
+	Algorithm     string `json:"alg" yaml:"alg"`
+	Secret        string `json:"secret,omitempty" yaml:"secret"`
+	PublicKey     string `json:"public_key,omitempty" yaml:"public_key"`
+	PublicKeyFile string `json:"public_key_file,omitempty" yaml:"public_key_file"`
+}
+
+type LimitConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Default berlaku per client untuk endpoint yang tidak disebut di Endpoints
+	Default   RateLimit            `json:"default" yaml:"default"`
+	Endpoints map[string]RateLimit `json:"endpoints" yaml:"endpoints"`
+	// TrustProxy: pakai X-Forwarded-For sebagai identitas client anonim.
+	// Di belakang reverse proxy semua client anonim berbagi satu bucket
+	// (alamat proxy) bila ini tidak diaktifkan.
+	TrustProxy    bool     `json:"trust_proxy" yaml:"trust_proxy"`
+	ClientIdleTTL Duration `json:"client_idle_ttl" yaml:"client_idle_ttl"`
+
+	// MaxConcurrentBuilds membatasi jumlah pohon cascading yang dibangun bersamaan.
+	// Bawaan 0 (tanpa batas) seperti perilaku lama; berlaku terpisah dari
+	// Enabled karena melindungi pool koneksi, bukan membatasi client.
+	// Builder memegang satu koneksi per level rekursi, jadi bila diisi jaga
+	// agar MaxConcurrentBuilds * kedalaman pohon tetap di bawah max_open_conns.
+	MaxConcurrentBuilds int      `json:"max_concurrent_builds" yaml:"max_concurrent_builds"`
+	BuildQueueTimeout   Duration `json:"build_queue_timeout" yaml:"build_queue_timeout"`
+}
+
+// RateLimit adalah token bucket: Rate token per detik dengan kapasitas Burst.
+type RateLimit struct {
+	Rate  float64 `json:"rate" yaml:"rate"`
+	Burst int     `json:"burst" yaml:"burst"`
+}
+
+var cfg Config
+
+// defaultConfig menyamakan nilai bawaan dengan perilaku sebelum ada konfigurasi.
+func defaultConfig() Config {
+	return Config{
+		Port: ":8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			// laporan cascading bisa lama dibangun, jangan terlalu pendek
+			WriteTimeout:    Duration(120 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		DB: DBConfig{
+			MaxOpenConns:    90,
+			MaxIdleConns:    45,
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+			ConnMaxLifetime: Duration(60 * time.Minute),
+			PingTimeout:     Duration(10 * time.Second),
+
+			RetryInitialBackoff: Duration(500 * time.Millisecond),
+			RetryMaxBackoff:     Duration(30 * time.Second),
+			StartupMaxAttempts:  10,
+			HealthCheckInterval: Duration(15 * time.Second),
+			QueryRetries:        2,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+			// "*" tidak mencakup Authorization, jadi disebut eksplisit
+			AllowedHeaders: []string{"*", "Authorization"},
+			MaxAge:         Duration(10 * time.Minute),
+		},
+		Auth: AuthConfig{
+			Leeway:       Duration(30 * time.Second),
+			RolesClaim:   "roles",
+			OpdClaim:     "kode_opd",
+			BappedaRoles: []string{"super_admin", "admin_bappeda"},
+
+			APIKeyHeader:         "X-API-Key",
+			APIKeyReloadInterval: Duration(30 * time.Second),
+		},
+		Batch: BatchConfig{
+			MaxTematik: 50,
+			Workers:    4,
+		},
+		MasterCacheTTL: Duration(10 * time.Minute),
+		CascadingRules: defaultCascadingRules(),
+		// rate limit dan batas build mati secara bawaan, sama seperti sebelum
+		// ada limiter. Nilai Default dan BuildQueueTimeout hanya titik awal
+		// bila diaktifkan.
+		Limit: LimitConfig{
+			Enabled:             false,
+			Default:             RateLimit{Rate: 5, Burst: 20},
+			ClientIdleTTL:       Duration(10 * time.Minute),
+			MaxConcurrentBuilds: 0,
+			BuildQueueTimeout:   Duration(5 * time.Second),
+		},
+	}
+}
+
+// loadConfig membaca konfigurasi dengan urutan prioritas:
+// default < file (-config / CASCADING_CONFIG) < environment < flag.
+func loadConfig(args []string) (Config, error) {
+	c := defaultConfig()
+
+	fs := flag.NewFlagSet("cascading-pemda-service", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CASCADING_CONFIG"), "path file konfigurasi (.json / .yaml)")
+	port := fs.String("port", "", "alamat listen, misal :8080")
+	dbURL := fs.String("db-url", "", "DSN database perencanaan")
+	maxOpen := fs.Int("db-max-open-conns", 0, "maksimal koneksi database terbuka")
+	maxIdle := fs.Int("db-max-idle-conns", 0, "maksimal koneksi database idle")
+	startNotReady := fs.Bool("db-start-not-ready", false, "jalankan server walau database belum bisa dihubungi")
+	corsOrigins := fs.String("cors-allowed-origins", "", "daftar origin CORS, pisahkan dengan koma")
+	if err := fs.Parse(args); err != nil {
+		return c, err
+	}
+
+	if *configFile != "" {
+		if err := loadConfigFile(*configFile, &c); err != nil {
+			return c, err
+		}
+	}
+
+	if err := applyEnv(&c); err != nil {
+		return c, err
+	}
+
+	// flag hanya menimpa kalau di-set eksplisit
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			c.Port = *port
+		case "db-url":
+			c.DB.URL = *dbURL
+		case "db-max-open-conns":
+			c.DB.MaxOpenConns = *maxOpen
+		case "db-max-idle-conns":
+			c.DB.MaxIdleConns = *maxIdle
+		case "db-start-not-ready":
+			c.DB.StartNotReady = *startNotReady
+		case "cors-allowed-origins":
+			c.CORS.AllowedOrigins = splitList(*corsOrigins)
+		}
+	})
+
+	if err := c.Validate(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func loadConfigFile(path string, c *Config) error {
+	return decodeFile(path, c)
+}
+
+// decodeFile membaca file JSON atau YAML sesuai ekstensinya ke v.
+func decodeFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("baca file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, v)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("format file tidak dikenal: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(c *Config) error {
+	if v := os.Getenv("PERENCANAAN_DB_URL"); v != "" {
+		c.DB.URL = v
+	}
+	if v := os.Getenv("CASCADING_PORT"); v != "" {
+		c.Port = v
+	}
+	if v := os.Getenv("CASCADING_CORS_ALLOWED_ORIGINS"); v != "" {
+		c.CORS.AllowedOrigins = splitList(v)
+	}
+	if v := os.Getenv("CASCADING_CORS_ALLOWED_METHODS"); v != "" {
+		c.CORS.AllowedMethods = splitList(v)
+	}
+	if v := os.Getenv("CASCADING_CORS_ALLOWED_HEADERS"); v != "" {
+		c.CORS.AllowedHeaders = splitList(v)
+	}
+	if v := os.Getenv("CASCADING_CORS_EXPOSED_HEADERS"); v != "" {
+		c.CORS.ExposedHeaders = splitList(v)
+	}
+	if v := os.Getenv("CASCADING_CORS_ALLOW_CREDENTIALS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_CORS_ALLOW_CREDENTIALS tidak valid: %w", err)
+		}
+		c.CORS.AllowCredentials = b
+	}
+
+	if v := os.Getenv("CASCADING_DB_START_NOT_READY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_DB_START_NOT_READY tidak valid: %w", err)
+		}
+		c.DB.StartNotReady = b
+	}
+
+	if v := os.Getenv("CASCADING_AUTH_ENABLED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_AUTH_ENABLED tidak valid: %w", err)
+		}
+		c.Auth.Enabled = b
+	}
+	if v := os.Getenv("CASCADING_LIMIT_ENABLED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_LIMIT_ENABLED tidak valid: %w", err)
+		}
+		c.Limit.Enabled = b
+	}
+	if v := os.Getenv("CASCADING_LIMIT_TRUST_PROXY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_LIMIT_TRUST_PROXY tidak valid: %w", err)
+		}
+		c.Limit.TrustProxy = b
+	}
+	if v := os.Getenv("CASCADING_LIMIT_RATE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("env CASCADING_LIMIT_RATE tidak valid: %w", err)
+		}
+		c.Limit.Default.Rate = f
+	}
+	if v, ok := os.LookupEnv("CASCADING_REALISASI_TABLE"); ok {
+		c.RealisasiTable = v
+	}
+	if v, ok := os.LookupEnv("CASCADING_REALISASI_ARAH_COLUMN"); ok {
+		c.RealisasiArahColumn = v
+	}
+	if v := os.Getenv("CASCADING_AUTH_ISSUER"); v != "" {
+		c.Auth.Issuer = v
+	}
+	if v := os.Getenv("CASCADING_AUTH_AUDIENCE"); v != "" {
+		c.Auth.Audience = v
+	}
+	if v := os.Getenv("CASCADING_AUTH_API_KEYS_FILE"); v != "" {
+		c.Auth.APIKeysFile = v
+	}
+	if v := os.Getenv("CASCADING_AUTH_BAPPEDA_ROLES"); v != "" {
+		c.Auth.BappedaRoles = splitList(v)
+	}
+	// kunci dari env ditambahkan ke kunci dari file konfigurasi
+	if v := os.Getenv("CASCADING_AUTH_HMAC_SECRET"); v != "" {
+		c.Auth.Keys = append(c.Auth.Keys, JWTKey{Kid: "env-hmac", Algorithm: "HS256", Secret: v})
+	}
+	if v := os.Getenv("CASCADING_AUTH_RSA_PUBLIC_KEY_FILE"); v != "" {
+		c.Auth.Keys = append(c.Auth.Keys, JWTKey{Kid: "env-rsa", Algorithm: "RS256", PublicKeyFile: v})
+	}
+
+	ints := map[string]*int{
+		"CASCADING_DB_MAX_OPEN_CONNS":           &c.DB.MaxOpenConns,
+		"CASCADING_DB_MAX_IDLE_CONNS":           &c.DB.MaxIdleConns,
+		"CASCADING_DB_STARTUP_MAX_ATTEMPTS":     &c.DB.StartupMaxAttempts,
+		"CASCADING_LIMIT_BURST":                 &c.Limit.Default.Burst,
+		"CASCADING_LIMIT_MAX_CONCURRENT_BUILDS": &c.Limit.MaxConcurrentBuilds,
+		"CASCADING_BATCH_MAX_TEMATIK":           &c.Batch.MaxTematik,
+		"CASCADING_BATCH_WORKERS":               &c.Batch.Workers,
+		"CASCADING_DB_QUERY_RETRIES":            &c.DB.QueryRetries,
+	}
+	for key, dst := range ints {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("env %s tidak valid: %w", key, err)
+			}
+			*dst = n
+		}
+	}
+
+	durations := map[string]*Duration{
+		"CASCADING_SERVER_READ_HEADER_TIMEOUT":   &c.Server.ReadHeaderTimeout,
+		"CASCADING_SERVER_READ_TIMEOUT":          &c.Server.ReadTimeout,
+		"CASCADING_SERVER_WRITE_TIMEOUT":         &c.Server.WriteTimeout,
+		"CASCADING_SERVER_IDLE_TIMEOUT":          &c.Server.IdleTimeout,
+		"CASCADING_SERVER_SHUTDOWN_TIMEOUT":      &c.Server.ShutdownTimeout,
+		"CASCADING_DB_CONN_MAX_IDLE_TIME":        &c.DB.ConnMaxIdleTime,
+		"CASCADING_DB_CONN_MAX_LIFETIME":         &c.DB.ConnMaxLifetime,
+		"CASCADING_DB_PING_TIMEOUT":              &c.DB.PingTimeout,
+		"CASCADING_DB_RETRY_INITIAL_BACKOFF":     &c.DB.RetryInitialBackoff,
+		"CASCADING_DB_RETRY_MAX_BACKOFF":         &c.DB.RetryMaxBackoff,
+		"CASCADING_AUTH_LEEWAY":                  &c.Auth.Leeway,
+		"CASCADING_AUTH_API_KEY_RELOAD_INTERVAL": &c.Auth.APIKeyReloadInterval,
+		"CASCADING_LIMIT_BUILD_QUEUE_TIMEOUT":    &c.Limit.BuildQueueTimeout,
+		"CASCADING_MASTER_CACHE_TTL":             &c.MasterCacheTTL,
+		"CASCADING_CORS_MAX_AGE":                 &c.CORS.MaxAge,
+		"CASCADING_DB_HEALTH_CHECK_INTERVAL":     &c.DB.HealthCheckInterval,
+	}
+	for key, dst := range durations {
+		if v := os.Getenv(key); v != "" {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				return fmt.Errorf("env %s tidak valid: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.DB.URL == "" {
+		errs = append(errs, errors.New("PERENCANAAN_DB_URL env tidak terdefinisi"))
+	} else if _, err := mysql.ParseDSN(c.DB.URL); err != nil {
+		errs = append(errs, fmt.Errorf("db url tidak valid: %w", err))
+	}
+	if c.Port == "" {
+		errs = append(errs, errors.New("port wajib diisi"))
+	}
+	if c.DB.MaxOpenConns <= 0 {
+		errs = append(errs, errors.New("db max_open_conns harus > 0"))
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, errors.New("db max_idle_conns harus di antara 0 dan max_open_conns"))
+	}
+	if c.Server.ReadHeaderTimeout <= 0 || c.Server.ReadTimeout <= 0 ||
+		c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("server timeout harus > 0"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server shutdown_timeout harus > 0"))
+	}
+	if c.DB.PingTimeout <= 0 {
+		errs = append(errs, errors.New("db ping_timeout harus > 0"))
+	}
+	if c.DB.RetryInitialBackoff <= 0 || c.DB.RetryMaxBackoff < c.DB.RetryInitialBackoff {
+		errs = append(errs, errors.New("db retry backoff harus > 0 dan retry_max_backoff >= retry_initial_backoff"))
+	}
+	if c.DB.StartupMaxAttempts < 0 || c.DB.QueryRetries < 0 {
+		errs = append(errs, errors.New("db startup_max_attempts dan query_retries tidak boleh negatif"))
+	}
+	if c.DB.HealthCheckInterval <= 0 {
+		errs = append(errs, errors.New("db health_check_interval harus > 0"))
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("cors allowed_origins tidak boleh kosong"))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			errs = append(errs, errors.New(`cors allow_credentials tidak boleh dipakai bersama origin "*"`))
+		}
+		if origin != "*" && !strings.Contains(origin, "://") {
+			errs = append(errs, fmt.Errorf("cors origin %q harus menyertakan skema, misal https://", origin))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, errors.New("cors max_age tidak boleh negatif"))
+	}
+	if c.Auth.Enabled {
+		if len(c.Auth.Keys) == 0 {
+			errs = append(errs, errors.New("auth aktif tetapi belum ada kunci JWT (auth.keys / CASCADING_AUTH_HMAC_SECRET)"))
+		}
+		if c.Auth.RolesClaim == "" || c.Auth.OpdClaim == "" {
+			errs = append(errs, errors.New("auth roles_claim dan opd_claim wajib diisi"))
+		}
+	}
+	if c.Auth.APIKeysFile != "" && (c.Auth.APIKeyHeader == "" || c.Auth.APIKeyReloadInterval <= 0) {
+		errs = append(errs, errors.New("auth api_key_header dan api_key_reload_interval wajib diisi bila api_keys_file dipakai"))
+	}
+	if c.Limit.Enabled {
+		if err := c.Limit.Default.validate("default"); err != nil {
+			errs = append(errs, err)
+		}
+		for path, rl := range c.Limit.Endpoints {
+			if err := rl.validate(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if c.Limit.ClientIdleTTL <= 0 {
+			errs = append(errs, errors.New("limit client_idle_ttl harus > 0"))
+		}
+	}
+	if c.Batch.MaxTematik < 1 || c.Batch.Workers < 1 {
+		errs = append(errs, errors.New("batch max_tematik dan workers minimal 1"))
+	}
+	if c.MasterCacheTTL < 0 {
+		errs = append(errs, errors.New("master_cache_ttl tidak boleh negatif"))
+	}
+	// nama tabel masuk ke query apa adanya, jadi dibatasi huruf, angka dan _
+	if c.RealisasiTable != "" && !tableNamePattern.MatchString(c.RealisasiTable) {
+		errs = append(errs, fmt.Errorf("realisasi_table %q tidak valid", c.RealisasiTable))
+	}
+	if c.RealisasiArahColumn != "" {
+		if c.RealisasiTable == "" {
+			errs = append(errs, errors.New("realisasi_arah_column butuh realisasi_table"))
+		} else if !tableNamePattern.MatchString(c.RealisasiArahColumn) {
+			errs = append(errs, fmt.Errorf("realisasi_arah_column %q tidak valid", c.RealisasiArahColumn))
+		}
+	}
+	if err := validateCascadingRules(c.CascadingRules); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Limit.MaxConcurrentBuilds < 0 || c.Limit.BuildQueueTimeout < 0 {
+		errs = append(errs, errors.New("limit max_concurrent_builds dan build_queue_timeout tidak boleh negatif"))
+	}
+	for _, key := range c.Auth.Keys {
+		if err := key.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted mengembalikan salinan konfigurasi yang aman untuk di-log.
+func (c Config) Redacted() Config {
+	out := c
+	out.DB.URL = redactDSN(c.DB.URL)
+
+	out.Auth.Keys = make([]JWTKey, len(c.Auth.Keys))
+	for i, key := range c.Auth.Keys {
+		if key.Secret != "" {
+			key.Secret = "xxxxx"
+		}
+		if key.PublicKey != "" {
+			key.PublicKey = "<pem>"
+		}
+		out.Auth.Keys[i] = key
+	}
+	return out
+}
+
+func (c Config) String() string {
+	data, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<config error: %v>", err)
+	}
+	return string(data)
+}
+
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "<redacted>"
+	}
+	if parsed.Passwd != "" {
+		parsed.Passwd = "xxxxx"
+	}
+	return parsed.FormatDSN()
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDSN = "user:rahasia@tcp(localhost:3306)/perencanaan"
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	t.Setenv("CASCADING_CONFIG", "")
+	t.Setenv("PERENCANAAN_DB_URL", "")
+	t.Setenv("CASCADING_PORT", "")
+	t.Setenv("CASCADING_CORS_ALLOWED_ORIGINS", "")
+
+	yamlFile := writeConfigFile(t, "config.yaml", `
+port: ":9000"
+db:
+  url: "user:file@tcp(file:3306)/perencanaan"
+  max_open_conns: 20
+  max_idle_conns: 10
+cors:
+  allowed_origins: ["https://file.example.go.id"]
+`)
+
+	// default saja
+	t.Setenv("PERENCANAAN_DB_URL", testDSN)
+	c, err := loadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != ":8080" || c.DB.MaxOpenConns != 90 || c.Server.WriteTimeout != Duration(120*time.Second) {
+		t.Errorf("default: port %s max_open %d write %s", c.Port, c.DB.MaxOpenConns, c.Server.WriteTimeout)
+	}
+
+	// file menimpa default, env menimpa file
+	t.Setenv("CASCADING_PORT", ":9100")
+	c, err = loadConfig([]string{"-config", yamlFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != ":9100" || c.DB.URL != testDSN || c.DB.MaxOpenConns != 20 || c.CORS.AllowedOrigins[0] != "https://file.example.go.id" {
+		t.Errorf("file+env: port %s url %s max_open %d cors %v", c.Port, c.DB.URL, c.DB.MaxOpenConns, c.CORS.AllowedOrigins)
+	}
+	// nilai yang tidak ada di file tetap default
+	if c.DB.PingTimeout != Duration(10*time.Second) {
+		t.Errorf("ping_timeout = %s, want default 10s", c.DB.PingTimeout)
+	}
+
+	// flag menimpa env dan file, termasuk file dari CASCADING_CONFIG
+	t.Setenv("CASCADING_CONFIG", yamlFile)
+	c, err = loadConfig([]string{"-port", ":9200", "-db-max-open-conns", "30", "-cors-allowed-origins", "https://a.go.id, https://b.go.id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != ":9200" || c.DB.MaxOpenConns != 30 || c.DB.MaxIdleConns != 10 || len(c.CORS.AllowedOrigins) != 2 {
+		t.Errorf("flag: port %s max_open %d max_idle %d cors %v", c.Port, c.DB.MaxOpenConns, c.DB.MaxIdleConns, c.CORS.AllowedOrigins)
+	}
+}
+
+func TestLoadConfigJSONAndErrors(t *testing.T) {
+	t.Setenv("CASCADING_CONFIG", "")
+	t.Setenv("PERENCANAAN_DB_URL", "")
+	t.Setenv("CASCADING_PORT", "")
+
+	jsonFile := writeConfigFile(t, "config.json", `{"db": {"url": "`+testDSN+`"}, "server": {"shutdown_timeout": "45s"}}`)
+	c, err := loadConfig([]string{"-config", jsonFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.ShutdownTimeout != Duration(45*time.Second) {
+		t.Errorf("shutdown_timeout = %s, want 45s", c.Server.ShutdownTimeout)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"tanpa db url", nil, "PERENCANAAN_DB_URL"},
+		{"ekstensi tidak dikenal", []string{"-config", writeConfigFile(t, "config.toml", "")}, "format file"},
+		{"durasi salah", []string{"-config", writeConfigFile(t, "bad.yaml", "server:\n  read_timeout: sebentar\n")}, "parse file"},
+		{"idle melebihi open", []string{"-db-url", testDSN, "-db-max-open-conns", "5", "-db-max-idle-conns", "10"}, "max_idle_conns"},
+	}
+	for _, tt := range tests {
+		if _, err := loadConfig(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want memuat %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	c := defaultConfig()
+	c.DB.URL = testDSN
+	c.Auth.Keys = []JWTKey{
+		{Kid: "hs", Algorithm: "HS256", Secret: "jwt-rahasia"},
+		{Kid: "rs", Algorithm: "RS256", PublicKey: "-----BEGIN PUBLIC KEY-----"},
+	}
+
+	out := c.String()
+	for _, secret := range []string{"rahasia", "jwt-rahasia", "BEGIN PUBLIC KEY"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("String() membocorkan %q: %s", secret, out)
+		}
+	}
+	red := c.Redacted()
+	if !strings.Contains(red.DB.URL, "user:xxxxx@tcp(localhost:3306)") || red.Auth.Keys[0].Secret != "xxxxx" || red.Auth.Keys[1].PublicKey != "<pem>" {
+		t.Errorf("redacted = %s %+v", red.DB.URL, red.Auth.Keys)
+	}
+	// salinan asli tidak ikut berubah
+	if c.DB.URL != testDSN || c.Auth.Keys[0].Secret != "jwt-rahasia" {
+		t.Error("Redacted mengubah konfigurasi asli")
+	}
+	if redactDSN("bukan dsn") != "<redacted>" || redactDSN("") != "" {
+		t.Error("dsn rusak harus disamarkan seluruhnya")
+	}
+}
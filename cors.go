@@ -0,0 +1,134 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	suffixes []originSuffix
+}
+
+// originSuffix untuk pola wildcard subdomain, misal https://*.madiunkota.go.id
+type originSuffix struct {
+	scheme string
+	suffix string
+}
+
+func newOriginMatcher(origins []string) originMatcher {
+	m := originMatcher{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimRight(origin, "/"))
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://")
+			m.suffixes = append(m.suffixes, originSuffix{scheme: scheme, suffix: strings.TrimPrefix(host, "*")})
+		default:
+			m.exact[origin] = true
+		}
+	}
+	return m
+}
+
+func (m originMatcher) allowed(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok {
+		return false
+	}
+	for _, s := range m.suffixes {
+		// subdomain wajib ada, https://madiunkota.go.id tidak cocok dengan https://*.madiunkota.go.id
+		if scheme == s.scheme && strings.HasSuffix(host, s.suffix) && len(host) > len(s.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware CORS berbasis allowlist origin dari konfigurasi
+func corsMiddleware(corsCfg CORSConfig, next http.Handler) http.Handler {
+	matcher := newOriginMatcher(corsCfg.AllowedOrigins)
+	allowMethods := strings.Join(corsCfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(corsCfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(corsCfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(time.Duration(corsCfg.MaxAge).Seconds()))
+	anyHeader := slices.Contains(corsCfg.AllowedHeaders, "*")
+
+	if matcher.any {
+		log.Print(`[WARN] CORS mengizinkan semua origin ("*"), set allowed_origins untuk production`)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// respons berbeda per origin, cache/proxy harus membedakannya
+		w.Header().Add("Vary", "Origin")
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		// bukan request cross-origin
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !matcher.allowed(origin) {
+			log.Printf("[WARN] CORS origin ditolak: %s %s %s", origin, r.Method, r.URL.Path)
+			if preflight {
+				writeError(w, http.StatusForbidden, "origin tidak diizinkan")
+				return
+			}
+			// tanpa header CORS browser akan memblokir respons
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if matcher.any && !corsCfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if corsCfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Preflight request (OPTIONS)
+		w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+		if anyHeader && corsCfg.AllowCredentials {
+			// "*" tidak berlaku untuk request dengan credentials, kembalikan header yang diminta
+			w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+		} else {
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if corsCfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := newOriginMatcher([]string{"https://app.example.go.id/", "https://*.example.go.id", "HTTP://Local.test"})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.go.id", true},
+		{"https://APP.example.go.id", true},
+		{"http://local.test", true},
+		{"https://sub.example.go.id", true},
+		{"https://a.b.example.go.id", true},
+		// wildcard wajib subdomain dan skema yang sama
+		{"https://example.go.id", false},
+		{"http://sub.example.go.id", false},
+		// bukan subdomain walau berakhiran sama
+		{"https://evil-example.go.id", false},
+		{"https://example.go.id.evil.com", false},
+		{"https://app.example.go.id:8443", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		if got := m.allowed(tt.origin); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+	if !newOriginMatcher([]string{"*"}).allowed("https://mana.saja") {
+		t.Error(`"*" harus mengizinkan semua origin`)
+	}
+}
+
+func testCORS(corsCfg CORSConfig, method, origin string, header http.Header) *httptest.ResponseRecorder {
+	handler := corsMiddleware(corsCfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, "/laporan/cascading_pemda", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	corsCfg := defaultConfig().CORS
+	corsCfg.AllowedOrigins = []string{"https://*.example.go.id"}
+	corsCfg.ExposedHeaders = []string{"Retry-After"}
+	corsCfg.MaxAge = Duration(10 * time.Minute)
+	preflightHeader := http.Header{"Access-Control-Request-Method": {"GET"}, "Access-Control-Request-Headers": {"Authorization"}}
+
+	// request biasa dari origin yang diizinkan
+	w := testCORS(corsCfg, http.MethodGet, "https://app.example.go.id", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.go.id" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "Retry-After" {
+		t.Errorf("GET diizinkan: %d %v", w.Code, w.Header())
+	}
+	if !slices.Contains(w.Header().Values("Vary"), "Origin") {
+		t.Errorf("Vary = %v, want Origin", w.Header().Values("Vary"))
+	}
+
+	// tanpa Origin tetap Vary: Origin supaya cache tidak tercampur
+	w = testCORS(corsCfg, http.MethodGet, "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" || !slices.Contains(w.Header().Values("Vary"), "Origin") {
+		t.Errorf("tanpa origin: %d %v", w.Code, w.Header())
+	}
+
+	// preflight diizinkan: 204 tanpa meneruskan ke handler
+	w = testCORS(corsCfg, http.MethodOptions, "https://app.example.go.id", preflightHeader)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST, OPTIONS" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" || w.Header().Get("Access-Control-Allow-Headers") != "*, Authorization" {
+		t.Errorf("preflight: %d %v", w.Code, w.Header())
+	}
+	if vary := w.Header().Values("Vary"); !slices.Contains(vary, "Access-Control-Request-Headers") {
+		t.Errorf("preflight Vary = %v", vary)
+	}
+
+	// origin ditolak: preflight 403, request biasa diteruskan tanpa header CORS
+	w = testCORS(corsCfg, http.MethodOptions, "https://evil-example.go.id", preflightHeader)
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight ditolak: %d %v", w.Code, w.Header())
+	}
+	w = testCORS(corsCfg, http.MethodGet, "https://evil-example.go.id", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("GET ditolak: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSMiddlewareCredentials(t *testing.T) {
+	corsCfg := defaultConfig().CORS
+	corsCfg.AllowedOrigins = []string{"https://app.example.go.id"}
+	corsCfg.AllowCredentials = true
+
+	// "*" tidak berlaku dengan credentials, header yang diminta dikembalikan
+	w := testCORS(corsCfg, http.MethodOptions, "https://app.example.go.id",
+		http.Header{"Access-Control-Request-Method": {"GET"}, "Access-Control-Request-Headers": {"Authorization, X-API-Key"}})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Authorization, X-API-Key" {
+		t.Errorf("preflight credentials: %d %v", w.Code, w.Header())
+	}
+
+	// origin "*" tanpa credentials menjawab "*"
+	w = testCORS(defaultConfig().CORS, http.MethodGet, "https://mana.saja", nil)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("origin * = %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
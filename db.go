@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// dbReady bernilai true selama ping terakhir ke database berhasil.
+var dbReady atomic.Bool
+
+// backoffDelay menghitung jeda exponential backoff dengan jitter
+// untuk percobaan ke-attempt (mulai dari 0).
+func backoffDelay(attempt int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	// jitter: ambil acak di rentang [delay/2, delay]
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+func pingDB(ctx context.Context, timeout time.Duration) error {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return db.PingContext(pingCtx)
+}
+
+// connectDB mencoba ping database dengan exponential backoff.
+// maxAttempts <= 0 berarti coba terus sampai ctx dibatalkan.
+func connectDB(ctx context.Context, dbCfg DBConfig, maxAttempts int) error {
+	var err error
+	for attempt := 0; maxAttempts <= 0 || attempt < maxAttempts; attempt++ {
+		err = pingDB(ctx, time.Duration(dbCfg.PingTimeout))
+		if err == nil {
+			dbReady.Store(true)
+			return nil
+		}
+
+		if maxAttempts > 0 && attempt == maxAttempts-1 {
+			log.Printf("Gagal terhubung ke database (percobaan %d): %v", attempt+1, err)
+			break
+		}
+		delay := backoffDelay(attempt, time.Duration(dbCfg.RetryInitialBackoff), time.Duration(dbCfg.RetryMaxBackoff))
+		log.Printf("Gagal terhubung ke database (percobaan %d): %v, coba lagi dalam %s", attempt+1, err, delay.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
+
+// monitorDB memeriksa koneksi database secara berkala dan memperbarui status ready.
+// Saat database putus, status ready dimatikan sampai ping berhasil lagi.
+func monitorDB(ctx context.Context, dbCfg DBConfig) {
+	ticker := time.NewTicker(time.Duration(dbCfg.HealthCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if dbReady.Load() {
+			if err := pingDB(ctx, time.Duration(dbCfg.PingTimeout)); err != nil {
+				dbReady.Store(false)
+				log.Printf("[WARN] koneksi database terputus: %v", err)
+			}
+			continue
+		}
+
+		if err := connectDB(ctx, dbCfg, 0); err == nil {
+			log.Print("Koneksi database tersambung kembali")
+		}
+	}
+}
+
+// isTransientDBError menandai error yang layak dicoba ulang:
+// koneksi putus, timeout jaringan, deadlock, dan kehabisan koneksi.
+func isTransientDBError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	// timeout atau pembatalan dari ctx bukan gangguan database, mengulang
+	// hanya memperpanjang request yang sudah ditinggalkan
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1040, // too many connections
+			1205, // lock wait timeout
+			1213, // deadlock
+			2006, // server has gone away
+			2013: // lost connection
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry menjalankan fn dan mengulang bila error bersifat sementara,
+// paling banyak dbCfg.QueryRetries kali. Jeda antar percobaan berhenti
+// begitu ctx dibatalkan, misalnya client sudah menutup koneksi atau
+// server sedang shutdown.
+func withRetry(ctx context.Context, dbCfg DBConfig, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if !isTransientDBError(err) || attempt >= dbCfg.QueryRetries {
+			return err
+		}
+
+		delay := backoffDelay(attempt, time.Duration(dbCfg.RetryInitialBackoff), time.Duration(dbCfg.RetryMaxBackoff))
+		log.Printf("[WARN] query gagal sementara (percobaan %d): %v, coba lagi dalam %s", attempt+1, err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// queryRetry sama dengan db.QueryContext tetapi mengulang query baca yang gagal sementara.
+func queryRetry(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := withRetry(ctx, cfg.DB, func() error {
+		var err error
+		rows, err = db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// requireDB menolak request dengan 503 selama database belum siap.
+func requireDB(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !dbReady.Load() {
+			w.Header().Set("Retry-After", "5")
+			writeError(w, http.StatusServiceUnavailable, "database belum siap, coba lagi nanti")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func liveHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, StatusResponse{Status: http.StatusOK, Message: "ok"})
+}
+
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	if !dbReady.Load() {
+		writeError(w, http.StatusServiceUnavailable, "database belum siap")
+		return
+	}
+	writeJSON(w, http.StatusOK, StatusResponse{Status: http.StatusOK, Message: "ready"})
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsTransientDBError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("query: %w", mysql.ErrInvalidConn), true},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1146}, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{errors.New("syntax error"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientDBError(tt.err); got != tt.want {
+			t.Errorf("isTransientDBError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetryStopsOnCancel(t *testing.T) {
+	dbCfg := DBConfig{QueryRetries: 5, RetryInitialBackoff: Duration(time.Hour), RetryMaxBackoff: Duration(time.Hour)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- withRetry(ctx, dbCfg, func() error {
+			calls++
+			return driver.ErrBadConn
+		})
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, driver.ErrBadConn) || calls != 1 {
+			t.Errorf("withRetry = %v setelah %d percobaan, want ErrBadConn setelah 1", err, calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("withRetry tidak berhenti saat ctx dibatalkan")
+	}
+}
+
+func TestWithRetryRetriesTransient(t *testing.T) {
+	dbCfg := DBConfig{QueryRetries: 2, RetryInitialBackoff: Duration(time.Millisecond), RetryMaxBackoff: Duration(time.Millisecond)}
+
+	calls := 0
+	err := withRetry(context.Background(), dbCfg, func() error {
+		calls++
+		return driver.ErrBadConn
+	})
+	if !errors.Is(err, driver.ErrBadConn) || calls != 3 {
+		t.Errorf("withRetry = %v setelah %d percobaan, want 3 percobaan", err, calls)
+	}
+}
+
+// fakeResult adalah jawaban fakeDB untuk satu query.
+type fakeResult struct {
+	cols []string
+	rows [][]driver.Value
+	err  error
+}
+
+// fakeQueryFunc menjawab query berdasarkan teks SQL dan argumennya.
+type fakeQueryFunc func(query string, args []driver.Value) fakeResult
+
+var (
+	fakeDrivers   sync.Map // nama dsn -> fakeQueryFunc
+	fakeDriverSeq atomic.Int64
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// useFakeDB mengganti db global dengan database palsu yang dijawab fn
+// selama test berjalan. fn dipanggil dari banyak goroutine bila handler
+// membangun tematik secara paralel.
+func useFakeDB(t *testing.T, fn fakeQueryFunc) {
+	t.Helper()
+	name := fmt.Sprintf("fake-%d", fakeDriverSeq.Add(1))
+	fakeDrivers.Store(name, fn)
+
+	fake, err := sql.Open("fakedb", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedReady, savedCfg := db, dbReady.Load(), cfg
+	db = fake
+	dbReady.Store(true)
+	cfg = defaultConfig()
+	initMasterCache(0)
+	t.Cleanup(func() {
+		fake.Close()
+		fakeDrivers.Delete(name)
+		db, cfg = saved, savedCfg
+		dbReady.Store(savedReady)
+	})
+}
+
+// fakeAnswer menyusun jawaban dari baris data, jumlah kolom mengikuti baris pertama.
+func fakeAnswer(rows ...[]driver.Value) fakeResult {
+	res := fakeResult{rows: rows}
+	if len(rows) > 0 {
+		res.cols = make([]string, len(rows[0]))
+		for i := range res.cols {
+			res.cols[i] = fmt.Sprintf("c%d", i)
+		}
+	}
+	return res
+}
+
+// fakeQueryContains menjawab query pertama yang memuat teks kunci, query
+// lain dijawab kosong.
+func fakeQueryContains(answers map[string]fakeResult) fakeQueryFunc {
+	return func(query string, args []driver.Value) fakeResult {
+		for key, res := range answers {
+			if strings.Contains(query, key) {
+				return res
+			}
+		}
+		return fakeResult{}
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fn, ok := fakeDrivers.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("fakedb %q tidak terdaftar", name)
+	}
+	return fakeConn{fn: fn.(fakeQueryFunc)}, nil
+}
+
+type fakeConn struct{ fn fakeQueryFunc }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{conn: c, query: query}, nil
+}
+func (fakeConn) Close() error { return nil }
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fakedb tidak mendukung transaksi")
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	res := c.fn(query, values)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return &fakeRows{cols: res.cols, rows: res.rows}, nil
+}
+
+type fakeStmt struct {
+	conn  fakeConn
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("fakedb hanya untuk query")
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return s.conn.QueryContext(context.Background(), s.query, named)
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
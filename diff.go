@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jenis perubahan node antar tahun
+const (
+	changeAdded     = "added"
+	changeRemoved   = "removed"
+	changeRenamed   = "renamed"
+	changeMoved     = "moved"
+	changeIndikator = "indikator"
+	changeTarget    = "target"
+	changePagu      = "pagu"
+)
+
+type TargetDiff struct {
+	Indikator string            `json:"indikator"`
+	TargetA   []TargetIndikator `json:"target_a"`
+	TargetB   []TargetIndikator `json:"target_b"`
+}
+
+type NodeDiff struct {
+	Changes          []string     `json:"changes"`
+	IdPohonA         int          `json:"id_pohon_a,omitempty"`
+	IdPohonB         int          `json:"id_pohon_b,omitempty"`
+	NamaPohonA       string       `json:"nama_pohon_a,omitempty"`
+	NamaPohonB       string       `json:"nama_pohon_b,omitempty"`
+	JenisPohon       JenisPohon   `json:"jenis_pohon"`
+	PathA            []PathNode   `json:"path_a,omitempty"`
+	PathB            []PathNode   `json:"path_b,omitempty"`
+	PaguA            Pagu         `json:"pagu_a"`
+	PaguB            Pagu         `json:"pagu_b"`
+	PaguDelta        Pagu         `json:"pagu_delta"`
+	IndikatorAdded   []string     `json:"indikator_added,omitempty"`
+	IndikatorRemoved []string     `json:"indikator_removed,omitempty"`
+	TargetChanged    []TargetDiff `json:"target_changed,omitempty"`
+}
+
+type DiffSummary struct {
+	Added            int  `json:"added"`
+	Removed          int  `json:"removed"`
+	Renamed          int  `json:"renamed"`
+	Moved            int  `json:"moved"`
+	IndikatorChanged int  `json:"indikator_changed"`
+	PaguA            Pagu `json:"pagu_a"`
+	PaguB            Pagu `json:"pagu_b"`
+	PaguDelta        Pagu `json:"pagu_delta"`
+}
+
+type TematikDiff struct {
+	TahunA   int         `json:"tahun_a"`
+	TahunB   int         `json:"tahun_b"`
+	TematikA PathNode    `json:"tematik_a"`
+	TematikB PathNode    `json:"tematik_b"`
+	Summary  DiffSummary `json:"summary"`
+	Nodes    []NodeDiff  `json:"nodes"`
+}
+
+type DiffResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    TematikDiff `json:"data"`
+}
+
+type flatNode struct {
+	node   PohonKinerjaPemda
+	parent int
+	path   []PathNode
+}
+
+// flattenTree meratakan pohon hasil buildTematik, urutan tetap pre-order.
+func flattenTree(nodes []PohonKinerjaPemda, parent int, path []PathNode, byId map[int]flatNode, order []int) []int {
+	for _, n := range nodes {
+		nodePath := append(path[:len(path):len(path)], PathNode{IdPohon: n.IdPohon, NamaPohon: n.NamaPohon, JenisPohon: n.JenisPohon})
+		childs := n.Childs
+		n.Childs = nil
+		byId[n.IdPohon] = flatNode{node: n, parent: parent, path: nodePath}
+		order = append(order, n.IdPohon)
+		order = flattenTree(childs, n.IdPohon, nodePath, byId, order)
+	}
+	return order
+}
+
+// targetsFor mengambil target indikator untuk tahun pohon, target tanpa
+// tahun dianggap berlaku.
+func targetsFor(ind IndikatorPohon, tahun int) []TargetIndikator {
+	var targets []TargetIndikator
+	for _, t := range ind.Target {
+		if t.Tahun == 0 || t.Tahun == tahun {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+func sameTargets(a, b []TargetIndikator) bool {
+	key := func(ts []TargetIndikator) []string {
+		var keys []string
+		for _, t := range ts {
+			keys = append(keys, strings.TrimSpace(t.Target)+"\x00"+foldText(t.Satuan))
+		}
+		slices.Sort(keys)
+		return keys
+	}
+	return slices.Equal(key(a), key(b))
+}
+
+// diffIndikator membandingkan indikator berdasarkan nama yang dinormalkan.
+func diffIndikator(d *NodeDiff, a, b []IndikatorPohon, tahunA, tahunB int) {
+	byName := make(map[string]IndikatorPohon, len(a))
+	for _, ind := range a {
+		byName[foldText(ind.Indikator)] = ind
+	}
+
+	seen := make(map[string]bool, len(b))
+	for _, ind := range b {
+		name := foldText(ind.Indikator)
+		seen[name] = true
+		old, ok := byName[name]
+		if !ok {
+			d.IndikatorAdded = append(d.IndikatorAdded, ind.Indikator)
+			continue
+		}
+		ta, tb := targetsFor(old, tahunA), targetsFor(ind, tahunB)
+		if !sameTargets(ta, tb) {
+			d.TargetChanged = append(d.TargetChanged, TargetDiff{Indikator: ind.Indikator, TargetA: ta, TargetB: tb})
+		}
+	}
+	for _, ind := range a {
+		if !seen[foldText(ind.Indikator)] {
+			d.IndikatorRemoved = append(d.IndikatorRemoved, ind.Indikator)
+		}
+	}
+
+	if len(d.IndikatorAdded) > 0 || len(d.IndikatorRemoved) > 0 {
+		d.Changes = append(d.Changes, changeIndikator)
+	}
+	if len(d.TargetChanged) > 0 {
+		d.Changes = append(d.Changes, changeTarget)
+	}
+}
+
+// diffTematik mencocokkan node tahun B ke tahun A lewat garis clone_from.
+// Bila dua node B berasal dari node A yang sama, yang pertama dianggap
+// lanjutan dan sisanya node baru.
+func diffTematik(treeA, treeB []PohonKinerjaPemda, tahunA, tahunB int, lin lineage) ([]NodeDiff, DiffSummary, error) {
+	flatA := make(map[int]flatNode)
+	orderA := flattenTree(treeA, 0, nil, flatA, nil)
+	flatB := make(map[int]flatNode)
+	orderB := flattenTree(treeB, 0, nil, flatB, nil)
+
+	bToA := make(map[int]int)
+	matchedA := make(map[int]bool)
+	for _, idB := range orderB {
+		idA, ok := lin.ancestorIn(idB, tahunA)
+		if ok && !matchedA[idA] {
+			if _, inTree := flatA[idA]; inTree {
+				bToA[idB] = idA
+				matchedA[idA] = true
+			}
+		}
+	}
+
+	var diffs []NodeDiff
+	var sum DiffSummary
+	var paguA, paguB paguSum
+	for _, t := range treeA {
+		paguA.add(t.Pagu)
+	}
+	for _, t := range treeB {
+		paguB.add(t.Pagu)
+	}
+	if err := errors.Join(paguA.err, paguB.err); err != nil {
+		return nil, sum, fmt.Errorf("total pagu: %w", err)
+	}
+	sum.PaguA, sum.PaguB = paguA.total, paguB.total
+	delta, err := sum.PaguB.Sub(sum.PaguA)
+	if err != nil {
+		return nil, sum, fmt.Errorf("selisih pagu: %w", err)
+	}
+	sum.PaguDelta = delta
+
+	for _, idB := range orderB {
+		b := flatB[idB]
+		d := NodeDiff{
+			IdPohonB:   idB,
+			NamaPohonB: b.node.NamaPohon,
+			JenisPohon: b.node.JenisPohon,
+			PathB:      b.path,
+			PaguB:      b.node.Pagu,
+			PaguDelta:  b.node.Pagu,
+		}
+
+		idA, ok := bToA[idB]
+		if !ok {
+			d.Changes = []string{changeAdded}
+			sum.Added++
+			diffs = append(diffs, d)
+			continue
+		}
+
+		a := flatA[idA]
+		d.IdPohonA = idA
+		d.NamaPohonA = a.node.NamaPohon
+		d.PathA = a.path
+		d.PaguA = a.node.Pagu
+		if d.PaguDelta, err = d.PaguB.Sub(d.PaguA); err != nil {
+			return nil, sum, fmt.Errorf("selisih pagu pohon %d: %w", idB, err)
+		}
+
+		if foldText(a.node.NamaPohon) != foldText(b.node.NamaPohon) {
+			d.Changes = append(d.Changes, changeRenamed)
+			sum.Renamed++
+		}
+		// pindah cabang: parent di B bukan lanjutan parent di A
+		if b.parent != 0 && bToA[b.parent] != a.parent {
+			d.Changes = append(d.Changes, changeMoved)
+			sum.Moved++
+		}
+		diffIndikator(&d, a.node.Indikators, b.node.Indikators, tahunA, tahunB)
+		if slices.Contains(d.Changes, changeIndikator) || slices.Contains(d.Changes, changeTarget) {
+			sum.IndikatorChanged++
+		}
+		if d.PaguDelta != 0 {
+			d.Changes = append(d.Changes, changePagu)
+		}
+
+		if len(d.Changes) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+
+	for _, idA := range orderA {
+		if matchedA[idA] {
+			continue
+		}
+		a := flatA[idA]
+		delta, err := Pagu(0).Sub(a.node.Pagu)
+		if err != nil {
+			return nil, sum, fmt.Errorf("selisih pagu pohon %d: %w", idA, err)
+		}
+		diffs = append(diffs, NodeDiff{
+			Changes:    []string{changeRemoved},
+			IdPohonA:   idA,
+			NamaPohonA: a.node.NamaPohon,
+			JenisPohon: a.node.JenisPohon,
+			PathA:      a.path,
+			PaguA:      a.node.Pagu,
+			PaguDelta:  delta,
+		})
+		sum.Removed++
+	}
+
+	return diffs, sum, nil
+}
+
+// tematikCounterpart mencari Tematik tahun B yang garis clone-nya berasal
+// dari tematikA.
+func tematikCounterpart(idxB *pohonIndex, lin lineage, tematikA, tahunA int) (int, bool) {
+	for id, e := range idxB.nodes {
+		if e.Parent != 0 || e.LevelPohon != 0 || e.JenisPohon != "Tematik" {
+			continue
+		}
+		if src, ok := lin.ancestorIn(id, tahunA); ok && src == tematikA {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func diffHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	tematikId, err := paramInt(q, "tematikId")
+	var tahunA, tahunB int
+	if err == nil {
+		tahunA, err = paramInt(q, "tahun_a")
+	}
+	if err == nil {
+		tahunB, err = paramInt(q, "tahun_b")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?tematikId=1&tahun_a=2025&tahun_b=2026")
+		return
+	}
+	if tahunA >= tahunB {
+		writeError(w, http.StatusBadRequest, "tahun_a harus lebih kecil dari tahun_b")
+		return
+	}
+
+	lin, err := loadLineage(ctx, tahunA, tahunB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	idxB, err := loadPohonIndex(ctx, tahunB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// tematikId adalah id di tahun_a, pasangannya di tahun_b bisa dipaksa lewat tematikIdB
+	tematikB, ok := 0, false
+	if v := q.Get("tematikIdB"); v != "" {
+		tematikB, err = strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid tematikIdB")
+			return
+		}
+		ok = true
+	} else {
+		tematikB, ok = tematikCounterpart(idxB, lin, tematikId, tahunA)
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("tematik %d tahun %d tidak punya lanjutan di tahun %d", tematikId, tahunA, tahunB))
+		return
+	}
+
+	// pagu tetap dihitung dari rekin, yang dibandingkan cukup indikator dan target
+	opts := optionsWithout(partRekin, partTagging, partSasaran, partTujuan, partProgram, partBidangUrusan, partUrusan, partKegiatan, partSubkegiatan)
+
+	treeA, err := buildTematik(ctx, tematikId, tahunA, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	treeB, err := buildTematik(ctx, tematikB, tahunB, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(treeA) == 0 || len(treeB) == 0 {
+		writeError(w, http.StatusNotFound, "tematik tidak ditemukan")
+		return
+	}
+
+	if kodeOpd := restrictedOpd(r.Context()); kodeOpd != "" {
+		if treeA, _, err = restrictTreeToOpd(treeA, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if treeB, _, err = restrictTreeToOpd(treeB, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	nodes, sum, err := diffTematik(treeA, treeB, tahunA, tahunB, lin)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	root := func(tree []PohonKinerjaPemda) PathNode {
+		if len(tree) == 0 {
+			return PathNode{}
+		}
+		return PathNode{IdPohon: tree[0].IdPohon, NamaPohon: tree[0].NamaPohon, JenisPohon: tree[0].JenisPohon}
+	}
+
+	writeLaporan(w, r, DiffResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Perbandingan Cascading Pemda Tahun %d dan %d", tahunA, tahunB),
+		Data: TematikDiff{
+			TahunA:   tahunA,
+			TahunB:   tahunB,
+			TematikA: root(treeA),
+			TematikB: root(treeB),
+			Summary:  sum,
+			Nodes:    nodes,
+		},
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffTematik(t *testing.T) {
+	// 2025 dilewati, pasangan dicari lewat rantai clone_from
+	lin := lineage{
+		1: {IdPohon: 1, Tahun: 2024}, 2: {IdPohon: 2, Tahun: 2024}, 3: {IdPohon: 3, Tahun: 2024},
+		4: {IdPohon: 4, Tahun: 2024}, 5: {IdPohon: 5, Tahun: 2024},
+		11: {IdPohon: 11, Tahun: 2025, CloneFrom: 1}, 12: {IdPohon: 12, Tahun: 2025, CloneFrom: 2},
+		13: {IdPohon: 13, Tahun: 2025, CloneFrom: 3}, 15: {IdPohon: 15, Tahun: 2025, CloneFrom: 5},
+		21: {IdPohon: 21, Tahun: 2026, CloneFrom: 11}, 22: {IdPohon: 22, Tahun: 2026, CloneFrom: 12},
+		23: {IdPohon: 23, Tahun: 2026, CloneFrom: 13}, 25: {IdPohon: 25, Tahun: 2026, CloneFrom: 15},
+		26: {IdPohon: 26, Tahun: 2026},
+		// clone kedua dari node yang sama dianggap node baru
+		27: {IdPohon: 27, Tahun: 2026, CloneFrom: 12},
+	}
+	treeA := []PohonKinerjaPemda{{
+		IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik", Pagu: 300,
+		Childs: []PohonKinerjaPemda{
+			{IdPohon: 2, NamaPohon: "Strategic Lama", Pagu: 200, Childs: []PohonKinerjaPemda{
+				{IdPohon: 3, NamaPohon: "Tactical", Pagu: 100},
+				{IdPohon: 4, NamaPohon: "Tactical Dihapus", Pagu: 100},
+			}},
+			{IdPohon: 5, NamaPohon: "Strategic Dua", Pagu: 100, Indikators: []IndikatorPohon{
+				{Indikator: "IKU A", Target: []TargetIndikator{{Target: "10", Satuan: "%", Tahun: 2024}}},
+			}},
+		},
+	}}
+	treeB := []PohonKinerjaPemda{{
+		IdPohon: 21, NamaPohon: "tematik ", JenisPohon: "Tematik", Pagu: 350,
+		Childs: []PohonKinerjaPemda{
+			{IdPohon: 22, NamaPohon: "Strategic Baru", Pagu: 200, Childs: []PohonKinerjaPemda{
+				{IdPohon: 27, NamaPohon: "Tactical", Pagu: 0},
+			}},
+			{IdPohon: 25, NamaPohon: "Strategic Dua", Pagu: 150, Indikators: []IndikatorPohon{
+				{Indikator: "iku a", Target: []TargetIndikator{{Target: "12", Satuan: "%", Tahun: 2026}}},
+				{Indikator: "IKU B"},
+			}, Childs: []PohonKinerjaPemda{
+				{IdPohon: 23, NamaPohon: "Tactical", Pagu: 150},
+			}},
+			{IdPohon: 26, NamaPohon: "Strategic Tiga", Pagu: 0},
+		},
+	}}
+
+	diffs, sum, err := diffTematik(treeA, treeB, 2024, 2026, lin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[[2]int][]string{
+		{1, 21}: {changePagu},
+		{2, 22}: {changeRenamed},
+		{0, 27}: {changeAdded},
+		{5, 25}: {changeIndikator, changeTarget, changePagu},
+		{3, 23}: {changeMoved, changePagu},
+		{0, 26}: {changeAdded},
+		{4, 0}:  {changeRemoved},
+	}
+	if len(diffs) != len(want) {
+		t.Errorf("diffs = %+v, want %d node", diffs, len(want))
+	}
+	for _, d := range diffs {
+		key := [2]int{d.IdPohonA, d.IdPohonB}
+		if !slices.Equal(d.Changes, want[key]) {
+			t.Errorf("node %v changes = %v, want %v", key, d.Changes, want[key])
+		}
+		if key == [2]int{5, 25} {
+			if !slices.Equal(d.IndikatorAdded, []string{"IKU B"}) || len(d.TargetChanged) != 1 {
+				t.Errorf("indikator node 25 = %+v", d)
+			}
+		}
+		if key == [2]int{4, 0} && d.PaguDelta != -100 {
+			t.Errorf("pagu_delta node 4 = %d, want -100", d.PaguDelta)
+		}
+	}
+
+	wantSum := DiffSummary{Added: 2, Removed: 1, Renamed: 1, Moved: 1, IndikatorChanged: 1, PaguA: 300, PaguB: 350, PaguDelta: 50}
+	if sum != wantSum {
+		t.Errorf("summary = %+v, want %+v", sum, wantSum)
+	}
+}
+
+// Pasangan di lineage yang tidak ada di pohon A (mis. tersaring status)
+// tidak boleh dicocokkan.
+func TestDiffTematikAncestorOutsideTree(t *testing.T) {
+	lin := lineage{
+		1:  {IdPohon: 1, Tahun: 2024},
+		2:  {IdPohon: 2, Tahun: 2024},
+		11: {IdPohon: 11, Tahun: 2025, CloneFrom: 1},
+		12: {IdPohon: 12, Tahun: 2025, CloneFrom: 2},
+	}
+	treeA := []PohonKinerjaPemda{{IdPohon: 1, NamaPohon: "Tematik"}}
+	treeB := []PohonKinerjaPemda{{IdPohon: 11, NamaPohon: "Tematik", Childs: []PohonKinerjaPemda{{IdPohon: 12, NamaPohon: "Strategic"}}}}
+
+	diffs, sum, err := diffTematik(treeA, treeB, 2024, 2025, lin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].IdPohonB != 12 || !slices.Equal(diffs[0].Changes, []string{changeAdded}) || sum.Added != 1 {
+		t.Errorf("diffs = %+v summary %+v, want node 12 added", diffs, sum)
+	}
+}
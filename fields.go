@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// tipe yang properti JSON-nya bisa dibatasi lewat ?fields=, dengan prefix
+// namanya. Properti PohonKinerjaPemda ditulis tanpa prefix.
+var sparseTypes = map[string]reflect.Type{
+	"":                reflect.TypeOf(PohonKinerjaPemda{}),
+	"rencana_kinerja": reflect.TypeOf(RencanaKinerjaAsn{}),
+	"indikator":       reflect.TypeOf(IndikatorPohon{}),
+}
+
+// FieldSet berisi properti yang diminta per tipe. Tipe yang tidak disebut
+// satu pun propertinya tetap diserialisasi lengkap, misal
+// fields=nama_pohon,rencana_kinerja menampilkan rekin apa adanya,
+// sedangkan fields=nama_pohon,rencana_kinerja,rencana_kinerja.pagu
+// hanya menampilkan pagu tiap rekin.
+type FieldSet map[reflect.Type]map[string]bool
+
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+func knownFields() map[string]reflect.Type {
+	known := make(map[string]reflect.Type)
+	for prefix, t := range sparseTypes {
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := jsonFieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			known[name] = t
+		}
+	}
+	return known
+}
+
+// parseFieldSet memvalidasi ?fields= terhadap properti PohonKinerjaPemda,
+// RencanaKinerjaAsn (rencana_kinerja.*) dan IndikatorPohon (indikator.*).
+func parseFieldSet(v string) (FieldSet, error) {
+	known := knownFields()
+	fs := make(FieldSet)
+
+	for _, name := range splitList(v) {
+		t, ok := known[name]
+		if !ok {
+			names := make([]string, 0, len(known))
+			for n := range known {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("field %q tidak dikenal, pilihan: %s", name, strings.Join(names, ","))
+		}
+		if fs[t] == nil {
+			fs[t] = make(map[string]bool)
+		}
+		_, field, found := strings.Cut(name, ".")
+		if !found {
+			field = name
+		}
+		fs[t][field] = true
+	}
+
+	if len(fs) == 0 {
+		return nil, fmt.Errorf("params fields tidak boleh kosong")
+	}
+	return fs, nil
+}
+
+// jsonObject menjaga urutan properti sesuai urutan field di struct.
+type jsonObject []jsonProperty
+
+type jsonProperty struct {
+	name  string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(p.name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(p.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// Project mengubah v menjadi nilai siap-encode yang hanya memuat properti terpilih.
+func (fs FieldSet) Project(v any) any {
+	return projector{fields: fs}.project(v)
+}
+
+// projector mengubah struct menjadi jsonObject berurutan. fields membatasi
+// properti (?fields=), rupiah menambahkan <nama>_rupiah di samping setiap
+// properti bertipe Pagu (?format_pagu=true).
+type projector struct {
+	fields FieldSet
+	rupiah bool
+}
+
+var paguType = reflect.TypeOf(Pagu(0))
+
+func (p projector) project(v any) any {
+	return p.value(reflect.ValueOf(v))
+}
+
+func (p projector) value(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return p.value(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = p.value(v.Index(i))
+		}
+		return out
+	case reflect.Struct:
+		// tipe dengan marshaller sendiri dibiarkan apa adanya
+		if _, ok := v.Interface().(json.Marshaler); ok {
+			return v.Interface()
+		}
+	default:
+		return v.Interface()
+	}
+
+	t := v.Type()
+	selected := p.fields[t]
+	obj := make(jsonObject, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if selected != nil && !selected[name] {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(f.Tag.Get("json"), ",omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		obj = append(obj, jsonProperty{name: name, value: p.value(fv)})
+		if p.rupiah && f.Type == paguType {
+			obj = append(obj, jsonProperty{name: name + "_rupiah", value: Pagu(fv.Int()).Rupiah()})
+		}
+	}
+	return obj
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldSet(t *testing.T) {
+	fs, err := parseFieldSet("nama_pohon, pagu,rencana_kinerja,rencana_kinerja.pagu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pohon := fs[reflect.TypeOf(PohonKinerjaPemda{})]
+	if !pohon["nama_pohon"] || !pohon["pagu"] || !pohon["rencana_kinerja"] || len(pohon) != 3 {
+		t.Errorf("field pohon = %v", pohon)
+	}
+	rekin := fs[reflect.TypeOf(RencanaKinerjaAsn{})]
+	if !rekin["pagu"] || len(rekin) != 1 {
+		t.Errorf("field rencana_kinerja = %v", rekin)
+	}
+
+	for _, v := range []string{"", " , ", "nama", "rencana_kinerja.nama"} {
+		if _, err := parseFieldSet(v); err == nil {
+			t.Errorf("parseFieldSet(%q) harus error", v)
+		}
+	}
+}
+
+func TestFieldSetProject(t *testing.T) {
+	fs, err := parseFieldSet("nama_pohon,pagu,rencana_kinerja,rencana_kinerja.id_rencana_kinerja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := []PohonKinerjaPemda{{
+		IdPohon:   7,
+		NamaPohon: "Tematik",
+		Pagu:      1500,
+		RencanaKinerjas: []RencanaKinerjaAsn{
+			{IdRekin: "R1", RencanaKinerja: "Rekin", Pagu: 1500},
+		},
+	}}
+
+	got, err := json.Marshal(fs.Project(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// urutan properti mengikuti urutan field struct
+	want := `[{"nama_pohon":"Tematik","pagu":1500,"rencana_kinerja":[{"id_rencana_kinerja":"R1"}]}]`
+	if string(got) != want {
+		t.Errorf("Project =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = json.Marshal(projector{fields: fs, rupiah: true}.project(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `[{"nama_pohon":"Tematik","pagu":1500,"pagu_rupiah":"Rp 1.500","rencana_kinerja":[{"id_rencana_kinerja":"R1"}]}]`
+	if string(got) != want {
+		t.Errorf("project rupiah =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFieldSetProjectUnselectedTypeIsComplete(t *testing.T) {
+	fs, err := parseFieldSet("nama_pohon,rencana_kinerja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekin := RencanaKinerjaAsn{IdRekin: "R1", RencanaKinerja: "Rekin", Pagu: 10}
+	got, err := json.Marshal(fs.Project(PohonKinerjaPemda{NamaPohon: "A", RencanaKinerjas: []RencanaKinerjaAsn{rekin}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := json.Marshal(rekin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"nama_pohon":"A","rencana_kinerja":[` + string(full) + `]}`
+	if string(got) != want {
+		t.Errorf("Project =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -2,6 +2,10 @@ module github.com/bappeda-dev-team/cascading-pemda-service
 
 go 1.24.3
 
-require github.com/go-sql-driver/mysql v1.9.3
+require (
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/golang-jwt/jwt/v5 v5.3.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require filippo.io/edwards25519 v1.1.0 // indirect
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// rollupKegiatan mengelompokkan rekin per kode kegiatan. Setiap rekin
+// dihitung sekali per kegiatan walaupun barisnya muncul berulang (satu baris
+// per subkegiatan), PaguRekinTerkait adalah jumlah pagu rekin-nya. Urutan
+// mengikuti kemunculan pertama.
+func rollupKegiatan(ctx context.Context, rekins []RencanaKinerjaAsn, tahun int, opts BuildOptions) ([]Kegiatan, error) {
+	var kegiatans []Kegiatan
+	kegIdx := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, rekin := range rekins {
+		kode := rekin.KodeKegiatan
+		if kode == "" || seen[rekin.IdRekin+"|"+kode] {
+			continue
+		}
+		seen[rekin.IdRekin+"|"+kode] = true
+
+		i, ok := kegIdx[kode]
+		if !ok {
+			i = len(kegiatans)
+			kegIdx[kode] = i
+			kegiatans = append(kegiatans, Kegiatan{KodeKegiatan: kode, NamaKegiatan: rekin.NamaKegiatan})
+		}
+		pagu, err := kegiatans[i].PaguRekinTerkait.Add(rekin.Pagu)
+		if err != nil {
+			return nil, fmt.Errorf("pagu kegiatan %s: %w", kode, err)
+		}
+		kegiatans[i].PaguRekinTerkait = pagu
+	}
+
+	// indikator diambil per kode unik, sama seperti indikator program
+	if opts.loads(partIndikator) && opts.shows(partKegiatan) {
+		for i := range kegiatans {
+			indList, err := getIndikatorsPKS(ctx, kegiatans[i].KodeKegiatan, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, fmt.Errorf("indikator kegiatan %s: %w", kegiatans[i].KodeKegiatan, err)
+			}
+			kegiatans[i].IndikatorKegiatan = indList
+		}
+	}
+
+	return kegiatans, nil
+}
+
+// rollupSubkegiatan sama dengan rollupKegiatan untuk kode subkegiatan.
+// Pagu rekin tidak dibagi ke subkegiatannya karena tidak ada dasar
+// pembagiannya di rincian belanja.
+func rollupSubkegiatan(ctx context.Context, rekins []RencanaKinerjaAsn, tahun int, opts BuildOptions) ([]Subkegiatan, error) {
+	var subkegiatans []Subkegiatan
+	subIdx := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, rekin := range rekins {
+		kode := rekin.KodeSubkegiatan
+		if kode == "" || seen[rekin.IdRekin+"|"+kode] {
+			continue
+		}
+		seen[rekin.IdRekin+"|"+kode] = true
+
+		i, ok := subIdx[kode]
+		if !ok {
+			i = len(subkegiatans)
+			subIdx[kode] = i
+			subkegiatans = append(subkegiatans, Subkegiatan{KodeSubkegiatan: kode, NamaSubkegiatan: rekin.NamaSubkegiatan})
+		}
+		pagu, err := subkegiatans[i].PaguRekinTerkait.Add(rekin.Pagu)
+		if err != nil {
+			return nil, fmt.Errorf("pagu subkegiatan %s: %w", kode, err)
+		}
+		subkegiatans[i].PaguRekinTerkait = pagu
+	}
+
+	if opts.loads(partIndikator) && opts.shows(partSubkegiatan) {
+		for i := range subkegiatans {
+			indList, err := getIndikatorsPKS(ctx, subkegiatans[i].KodeSubkegiatan, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, fmt.Errorf("indikator subkegiatan %s: %w", subkegiatans[i].KodeSubkegiatan, err)
+			}
+			subkegiatans[i].IndikatorSubkegiatan = indList
+		}
+	}
+
+	return subkegiatans, nil
+}
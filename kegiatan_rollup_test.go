@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRollupPaguRekinTerkait(t *testing.T) {
+	opts := defaultBuildOptions()
+	delete(opts.parts, partIndikator)
+
+	rekins := []RencanaKinerjaAsn{
+		{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100},
+		{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100},
+		{IdRekin: "R2", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 30},
+	}
+
+	kegiatans, err := rollupKegiatan(context.Background(), rekins, 2025, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kegiatans) != 1 || kegiatans[0].PaguRekinTerkait != 130 {
+		t.Errorf("kegiatan = %+v, want satu kegiatan dengan pagu 130", kegiatans)
+	}
+
+	subkegiatans, err := rollupSubkegiatan(context.Background(), rekins, 2025, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pagu R1 tidak dibagi, terhitung penuh di kedua subkegiatannya
+	want := map[string]Pagu{"1.01.01.2.01.0001": 130, "1.01.01.2.01.0002": 100}
+	if len(subkegiatans) != len(want) {
+		t.Fatalf("subkegiatan = %+v", subkegiatans)
+	}
+	for _, s := range subkegiatans {
+		if s.PaguRekinTerkait != want[s.KodeSubkegiatan] {
+			t.Errorf("subkegiatan %s pagu = %d, want %d", s.KodeSubkegiatan, s.PaguRekinTerkait, want[s.KodeSubkegiatan])
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// panjang kode mengikuti getProgramFromKegiatan (7) dan getKegiatanFromSubkegiatan (12)
+const (
+	kodeProgramLen  = 7
+	kodeKegiatanLen = 12
+)
+
+type KodeLookup struct {
+	Kode  string `json:"kode"`
+	Level string `json:"level"`
+	Nama  string `json:"nama"`
+	Tahun int    `json:"tahun"`
+	// Pagu adalah jumlah pagu rekin yang memakai kode ini, setiap rekin
+	// sekali dengan pagu penuhnya karena rincian belanja tidak dipecah
+	// per subkegiatan
+	Pagu     Pagu          `json:"pagu"`
+	Tematiks []TematikPagu `json:"tematiks"`
+	Nodes    []RekinNode   `json:"nodes"`
+}
+
+type KodeLookupResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    KodeLookup `json:"data"`
+}
+
+// kodeSegmen adalah panjang segmen kode bertitik berurutan: urusan, bidang
+// urusan, program, kegiatan (dua segmen) dan subkegiatan. Program penunjang
+// lintas urusan memakai X, misal X.XX.01.
+var kodeSegmen = []int{1, 2, 2, 1, 2, 4}
+
+// kodeLevel menentukan level kode dari jumlah segmennya dan memastikan
+// setiap segmen berisi angka (atau X) dengan panjang yang benar.
+func kodeLevel(kode string) (string, error) {
+	segs := strings.Split(kode, ".")
+	var level string
+	switch len(segs) {
+	case 3:
+		level = "program"
+	case 5:
+		level = "kegiatan"
+	case 6:
+		level = "subkegiatan"
+	default:
+		return "", fmt.Errorf("kode %q bukan kode program, kegiatan atau subkegiatan", kode)
+	}
+	for i, seg := range segs {
+		if len(seg) != kodeSegmen[i] || strings.Trim(seg, "0123456789Xx") != "" {
+			return "", fmt.Errorf("kode %q tidak valid: segmen ke-%d harus %d digit", kode, i+1, kodeSegmen[i])
+		}
+	}
+	return level, nil
+}
+
+func kodeNama(ctx context.Context, kode, level string) (string, error) {
+	switch level {
+	case "program":
+		prog, err := getProgramFromKegiatan(ctx, kode)
+		return prog.NamaProgram, err
+	case "kegiatan":
+		keg, err := getKegiatanFromSubkegiatan(ctx, kode)
+		return keg.NamaKegiatan, err
+	}
+
+	var nama sql.NullString
+	err := withRetry(ctx, cfg.DB, func() error {
+		return db.QueryRowContext(ctx, `SELECT nama_subkegiatan FROM tb_subkegiatan WHERE kode_subkegiatan = ? LIMIT 1`, kode).Scan(&nama)
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+	return nama.String, nil
+}
+
+func kodeLookupHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	kode := strings.TrimSpace(r.URL.Query().Get("kode"))
+	if kode == "" {
+		writeError(w, http.StatusBadRequest, "params kode is required, misal: ?kode=1.02.02.2.01&tahun=2025")
+		return
+	}
+	tahun, err := paramInt(r.URL.Query(), "tahun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?kode=1.02.02.2.01&tahun=2025")
+		return
+	}
+	level, err := kodeLevel(kode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	nama, err := kodeNama(ctx, kode, level)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// kode dipakai sebagai prefix LIKE, wildcard di dalamnya di-escape
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(kode)
+	rekins, err := queryRekinNodes(ctx, tahun, "sub_rekin.kode_subkegiatan LIKE CONCAT(?, '%')", escaped)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	idx, err := loadPohonIndex(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// rekin tanpa Tematik tidak ikut, sama seperti di cascading
+	nodes, tematiks, total, _, err := groupRekinNodes(idx, rekins, restrictedOpd(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeLaporan(w, r, KodeLookupResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Cascading untuk %s %s Tahun %d", level, kode, tahun),
+		Data: KodeLookup{
+			Kode:     kode,
+			Level:    level,
+			Nama:     nama,
+			Tahun:    tahun,
+			Pagu:     total,
+			Tematiks: tematiks,
+			Nodes:    nodes,
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKodeLevel(t *testing.T) {
+	tests := []struct {
+		kode  string
+		level string
+	}{
+		{"1.02.02", "program"},
+		{"X.XX.01", "program"},
+		{"1.02.02.2.01", "kegiatan"},
+		{"1.02.02.2.01.0001", "subkegiatan"},
+		// salah bentuk
+		{"1.02.0", ""},
+		{"1.02.02.2", ""},
+		{"1020202201", ""},
+		{"1.02.02.2.01.01", ""},
+		{"1.02.02.2.01.0001.1", ""},
+		{"1.2.002.2.01", ""},
+		{"1.02.02.a.01", ""},
+		{"1.02.02..01", ""},
+		{"1.02.02.2.01.00%1", ""},
+	}
+	for _, tt := range tests {
+		level, err := kodeLevel(tt.kode)
+		if tt.level == "" {
+			if err == nil {
+				t.Errorf("kodeLevel(%q) = %q, want error", tt.kode, level)
+			}
+			continue
+		}
+		if err != nil || level != tt.level {
+			t.Errorf("kodeLevel(%q) = %q, %v, want %q", tt.kode, level, err, tt.level)
+		}
+	}
+}
+
+func TestKodeLookupRejectsMalformedKode(t *testing.T) {
+	w := httptest.NewRecorder()
+	kodeLookupHandler(w, httptest.NewRequest("GET", "/laporan/cascading_pemda/kode?kode=1.02.02.2.1&tahun=2025", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 untuk kode salah bentuk", w.Code)
+	}
+}
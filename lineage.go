@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type lineageEntry struct {
+	IdPohon   int
+	Tahun     int
+	CloneFrom int
+}
+
+// lineage memetakan node ke sumber clone-nya lintas tahun. clone_from di
+// tahun yang sama adalah pokin OPD hasil clone node pemda, bukan riwayat
+// antar tahun, sehingga diabaikan saat menelusuri garis keturunan.
+type lineage map[int]lineageEntry
+
+// loadLineage memuat id, tahun dan clone_from untuk tahun from..to.
+func loadLineage(ctx context.Context, from, to int) (lineage, error) {
+	rows, err := queryRetry(ctx, `SELECT id, tahun, clone_from
+		FROM tb_pohon_kinerja
+		WHERE tahun BETWEEN ? AND ?`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query lineage error: %w", err)
+	}
+	defer rows.Close()
+
+	l := make(lineage)
+	for rows.Next() {
+		var e lineageEntry
+		var cloneFrom sql.NullInt64
+		if err := rows.Scan(&e.IdPohon, &e.Tahun, &cloneFrom); err != nil {
+			return nil, fmt.Errorf("scan lineage error: %w", err)
+		}
+		e.CloneFrom = int(cloneFrom.Int64)
+		l[e.IdPohon] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return l, nil
+}
+
+// source mengembalikan sumber clone antar tahun dari node, ok=false bila
+// node tidak punya sumber di tahun sebelumnya yang termuat.
+func (l lineage) source(id int) (lineageEntry, bool) {
+	e, ok := l[id]
+	if !ok || e.CloneFrom == 0 {
+		return lineageEntry{}, false
+	}
+	src, ok := l[e.CloneFrom]
+	if !ok || src.Tahun >= e.Tahun {
+		return lineageEntry{}, false
+	}
+	return src, true
+}
+
+// ancestorIn menelusuri clone_from mundur sampai ketemu versi node di
+// tahun yang diminta, melewati tahun-tahun di antaranya.
+func (l lineage) ancestorIn(id, tahun int) (int, bool) {
+	seen := make(map[int]bool)
+	for cur, ok := l[id]; ok && !seen[cur.IdPohon]; cur, ok = l.source(cur.IdPohon) {
+		seen[cur.IdPohon] = true
+		if cur.Tahun == tahun {
+			return cur.IdPohon, true
+		}
+		if cur.Tahun < tahun {
+			break
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// PohonVersion adalah satu versi node di satu tahun pada garis clone_from.
+type PohonVersion struct {
+	IdPohon    int              `json:"id_pohon"`
+	Tahun      int              `json:"tahun"`
+	NamaPohon  string           `json:"nama_pohon"`
+	JenisPohon JenisPohon       `json:"jenis_pohon"`
+	LevelPohon int              `json:"level_pohon"`
+	KodeOpd    string           `json:"kode_opd,omitempty"`
+	Parent     int              `json:"parent"`
+	NamaParent string           `json:"nama_parent,omitempty"`
+	Status     string           `json:"status"`
+	CloneFrom  int              `json:"clone_from"`
+	Pagu       Pagu             `json:"pagu"`
+	Indikators []IndikatorPohon `json:"indikator"`
+	BrokenLink bool             `json:"broken_link"`
+	Keterangan string           `json:"keterangan_audit,omitempty"`
+	Diminta    bool             `json:"diminta,omitempty"`
+}
+
+type LineageAudit struct {
+	IdPohon     int            `json:"id_pohon"`
+	Versions    []PohonVersion `json:"versions"`
+	BrokenLinks int            `json:"broken_links"`
+}
+
+type LineageResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    LineageAudit `json:"data"`
+}
+
+const pohonVersionQuery = `SELECT pokin.id, pokin.tahun, pokin.nama_pohon, pokin.jenis_pohon, pokin.level_pohon,
+		pokin.kode_opd, pokin.parent, parent.nama_pohon, pokin.status, pokin.clone_from
+	FROM tb_pohon_kinerja pokin
+	LEFT JOIN tb_pohon_kinerja parent ON parent.id = pokin.parent`
+
+func scanPohonVersion(scan func(dest ...any) error) (PohonVersion, error) {
+	var v PohonVersion
+	var kodeOpd, namaParent, status sql.NullString
+	var cloneFrom sql.NullInt64
+	if err := scan(&v.IdPohon, &v.Tahun, &v.NamaPohon, &v.JenisPohon, &v.LevelPohon,
+		&kodeOpd, &v.Parent, &namaParent, &status, &cloneFrom); err != nil {
+		return v, err
+	}
+	v.KodeOpd = kodeOpd.String
+	v.NamaParent = namaParent.String
+	v.Status = status.String
+	v.CloneFrom = int(cloneFrom.Int64)
+	return v, nil
+}
+
+func getPohonVersion(ctx context.Context, id int) (PohonVersion, bool, error) {
+	var v PohonVersion
+	err := withRetry(ctx, cfg.DB, func() error {
+		var err error
+		v, err = scanPohonVersion(db.QueryRowContext(ctx, pohonVersionQuery+` WHERE pokin.id = ?`, id).Scan)
+		return err
+	})
+	if err == sql.ErrNoRows {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, fmt.Errorf("query pohon %d error: %w", id, err)
+	}
+	return v, true, nil
+}
+
+// getClonedVersions mengambil node tahun berikutnya yang di-clone dari id.
+// clone_from di tahun yang sama adalah pokin OPD, bukan versi baru.
+func getClonedVersions(ctx context.Context, id, tahun int) ([]PohonVersion, error) {
+	rows, err := queryRetry(ctx, pohonVersionQuery+` WHERE pokin.clone_from = ? AND pokin.tahun > ?`, id, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query clone %d error: %w", id, err)
+	}
+	defer rows.Close()
+
+	var versions []PohonVersion
+	for rows.Next() {
+		v, err := scanPohonVersion(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("scan clone error: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return versions, nil
+}
+
+// versionPagu menghitung pagu node dengan aturan yang sama seperti cascading:
+// rekin sendiri bila disetujui ditambah pagu seluruh turunannya.
+func versionPagu(ctx context.Context, v PohonVersion) (Pagu, error) {
+	opts := BuildOptions{parts: map[string]bool{}}
+
+	var pagu paguSum
+	if v.Status == statusDisetujui {
+		source, err := findPokinById(ctx, v.IdPohon, v.Tahun, opts)
+		if err != nil {
+			return 0, err
+		}
+		rekinPagu, err := paguRekin(source.RencanaKinerjas)
+		if err != nil {
+			return 0, fmt.Errorf("pagu pohon %d: %w", v.IdPohon, err)
+		}
+		pagu.add(rekinPagu)
+	}
+	_, childPagu, err := getChildPokins(ctx, v.IdPohon, v.Tahun, opts, 2)
+	if err != nil {
+		return 0, err
+	}
+	pagu.add(childPagu)
+	if pagu.err != nil {
+		return 0, fmt.Errorf("pagu pohon %d: %w", v.IdPohon, pagu.err)
+	}
+	return pagu.total, nil
+}
+
+// auditLineage menelusuri clone_from mundur sampai sumber pertama dan maju
+// ke semua clone di tahun-tahun berikutnya.
+func auditLineage(ctx context.Context, start PohonVersion) ([]PohonVersion, error) {
+	start.Diminta = true
+	versions := []PohonVersion{start}
+	seen := map[int]bool{start.IdPohon: true}
+
+	// mundur
+	for cur := 0; versions[cur].CloneFrom != 0; cur = len(versions) - 1 {
+		v := &versions[cur]
+		src, ok, err := getPohonVersion(ctx, v.CloneFrom)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			v.BrokenLink = true
+			v.Keterangan = fmt.Sprintf("sumber clone %d sudah tidak ada", v.CloneFrom)
+			break
+		}
+		if src.Tahun > v.Tahun {
+			v.BrokenLink = true
+			v.Keterangan = fmt.Sprintf("sumber clone %d berada di tahun %d yang lebih baru", src.IdPohon, src.Tahun)
+			break
+		}
+		if src.Tahun == v.Tahun || seen[src.IdPohon] {
+			// clone OPD di tahun yang sama, bukan riwayat antar tahun
+			break
+		}
+		seen[src.IdPohon] = true
+		versions = append(versions, src)
+	}
+
+	// maju, clone bisa bercabang
+	queue := []PohonVersion{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		next, err := getClonedVersions(ctx, cur.IdPohon, cur.Tahun)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range next {
+			if seen[v.IdPohon] {
+				continue
+			}
+			seen[v.IdPohon] = true
+			versions = append(versions, v)
+			queue = append(queue, v)
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Tahun != versions[j].Tahun {
+			return versions[i].Tahun < versions[j].Tahun
+		}
+		return versions[i].IdPohon < versions[j].IdPohon
+	})
+	return versions, nil
+}
+
+func lineageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := paramInt(r.URL.Query(), "id_pohon")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?id_pohon=123")
+		return
+	}
+
+	start, ok, err := getPohonVersion(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("pohon %d tidak ditemukan", id))
+		return
+	}
+	if !tahunAllowed(r.Context(), start.Tahun) {
+		writeError(w, http.StatusForbidden, "akses ditolak: tahun di luar scope")
+		return
+	}
+	kodeOpd := restrictedOpd(r.Context())
+	indexes := make(map[int]*pohonIndex)
+	visible := func(v PohonVersion) (bool, error) {
+		if kodeOpd == "" {
+			return true, nil
+		}
+		idx, ok := indexes[v.Tahun]
+		if !ok {
+			var err error
+			if idx, err = loadPohonIndex(ctx, v.Tahun); err != nil {
+				return false, err
+			}
+			indexes[v.Tahun] = idx
+		}
+		return idx.visibleTo(v.IdPohon, kodeOpd), nil
+	}
+
+	ok, err = visible(start)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusForbidden, "pohon ini bukan milik OPD anda")
+		return
+	}
+
+	versions, err := auditLineage(ctx, start)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// versi di tahun di luar scope, atau yang di tahun itu bukan milik OPD
+	// pemanggil (misal pohon pindah OPD), tidak ditampilkan
+	kept := versions[:0]
+	for _, v := range versions {
+		if !tahunAllowed(r.Context(), v.Tahun) {
+			continue
+		}
+		ok, err := visible(v)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if ok {
+			kept = append(kept, v)
+		}
+	}
+	versions = kept
+
+	audit := LineageAudit{IdPohon: id}
+	for i := range versions {
+		v := &versions[i]
+		v.Indikators, err = getIndikators(ctx, v.IdPohon, v.Tahun, true)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// setiap versi membangun subtree seperti cascading, jadi ikut antre
+		// slot build satu per satu
+		if !builds.acquire(ctx) {
+			w.Header().Set("Retry-After", retryAfterSeconds(builds.timeout))
+			writeError(w, http.StatusTooManyRequests, "server sedang sibuk membangun laporan, coba lagi nanti")
+			return
+		}
+		v.Pagu, err = versionPagu(ctx, *v)
+		builds.release()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if v.BrokenLink {
+			audit.BrokenLinks++
+		}
+	}
+	audit.Versions = versions
+
+	writeLaporan(w, r, LineageResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Riwayat Clone Pohon %d", id),
+		Data:    audit,
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeVersions menjawab query auditLineage dari daftar versi.
+func fakeVersions(versions ...PohonVersion) fakeQueryFunc {
+	row := func(v PohonVersion) []driver.Value {
+		return []driver.Value{int64(v.IdPohon), int64(v.Tahun), v.NamaPohon, string(v.JenisPohon), int64(v.LevelPohon),
+			v.KodeOpd, int64(v.Parent), v.NamaParent, v.Status, int64(v.CloneFrom)}
+	}
+	return func(query string, args []driver.Value) fakeResult {
+		var res [][]driver.Value
+		for _, v := range versions {
+			switch {
+			case strings.Contains(query, "WHERE pokin.id = ?"):
+				if int64(v.IdPohon) == args[0].(int64) {
+					res = append(res, row(v))
+				}
+			case strings.Contains(query, "WHERE pokin.clone_from = ?"):
+				if int64(v.CloneFrom) == args[0].(int64) && int64(v.Tahun) > args[1].(int64) {
+					res = append(res, row(v))
+				}
+			}
+		}
+		answer := fakeAnswer(res...)
+		answer.cols = make([]string, 10)
+		return answer
+	}
+}
+
+func auditIds(versions []PohonVersion) []int {
+	var ids []int
+	for _, v := range versions {
+		ids = append(ids, v.IdPohon)
+	}
+	return ids
+}
+
+func TestAuditLineage(t *testing.T) {
+	useFakeDB(t, fakeVersions(
+		PohonVersion{IdPohon: 1, Tahun: 2024},
+		PohonVersion{IdPohon: 11, Tahun: 2025, CloneFrom: 1},
+		// clone bercabang ke dua node di tahun berikutnya
+		PohonVersion{IdPohon: 21, Tahun: 2026, CloneFrom: 11},
+		PohonVersion{IdPohon: 22, Tahun: 2026, CloneFrom: 11},
+		// pokin OPD di tahun yang sama bukan versi baru
+		PohonVersion{IdPohon: 12, Tahun: 2025, CloneFrom: 11},
+	))
+	start, _, err := getPohonVersion(context.Background(), 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := auditLineage(context.Background(), start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := auditIds(versions); len(got) != 4 || got[0] != 1 || got[1] != 11 || got[2] != 21 || got[3] != 22 {
+		t.Fatalf("versi = %v, want [1 11 21 22]", got)
+	}
+	for _, v := range versions {
+		if v.BrokenLink {
+			t.Errorf("versi %d broken_link, want tidak", v.IdPohon)
+		}
+		if v.Diminta != (v.IdPohon == 11) {
+			t.Errorf("versi %d diminta = %v", v.IdPohon, v.Diminta)
+		}
+	}
+}
+
+func TestAuditLineageBrokenLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		versions   []PohonVersion
+		start      int
+		broken     int
+		keterangan string
+	}{
+		{"sumber dihapus", []PohonVersion{
+			{IdPohon: 11, Tahun: 2025, CloneFrom: 1},
+		}, 11, 11, "sudah tidak ada"},
+		{"sumber di tahun lebih baru", []PohonVersion{
+			{IdPohon: 11, Tahun: 2025, CloneFrom: 31},
+			{IdPohon: 31, Tahun: 2027},
+		}, 11, 11, "lebih baru"},
+		{"putus di tengah rantai", []PohonVersion{
+			{IdPohon: 11, Tahun: 2025, CloneFrom: 2},
+			{IdPohon: 21, Tahun: 2026, CloneFrom: 11},
+		}, 21, 11, "sumber clone 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeDB(t, fakeVersions(tt.versions...))
+			start, _, err := getPohonVersion(context.Background(), tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			versions, err := auditLineage(context.Background(), start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var broken []PohonVersion
+			for _, v := range versions {
+				if v.BrokenLink {
+					broken = append(broken, v)
+				}
+			}
+			if len(broken) != 1 || broken[0].IdPohon != tt.broken || !strings.Contains(broken[0].Keterangan, tt.keterangan) {
+				t.Errorf("broken = %+v, want versi %d dengan %q", broken, tt.broken, tt.keterangan)
+			}
+		})
+	}
+}
+
+// pagu setiap versi ikut antre slot build, bukan dibangun di luar batas
+func TestLineageHandlerWaitsForBuildSlot(t *testing.T) {
+	useFakeDB(t, fakeVersions(PohonVersion{IdPohon: 11, Tahun: 2025}))
+	saved := builds
+	builds = newBuildLimiter(LimitConfig{MaxConcurrentBuilds: 1, BuildQueueTimeout: Duration(10 * time.Millisecond)})
+	t.Cleanup(func() { builds = saved })
+
+	if !builds.acquire(context.Background()) {
+		t.Fatal("slot pertama harus didapat")
+	}
+	w := httptest.NewRecorder()
+	lineageHandler(w, httptest.NewRequest("GET", "/laporan/cascading_pemda/lineage?id_pohon=11", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("slot penuh: status %d, want 429: %s", w.Code, w.Body.String())
+	}
+
+	builds.release()
+	w = httptest.NewRecorder()
+	lineageHandler(w, httptest.NewRequest("GET", "/laporan/cascading_pemda/lineage?id_pohon=11", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("slot kosong: status %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
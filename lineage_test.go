@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestLineageAncestorIn(t *testing.T) {
+	lin := lineage{
+		1:  {IdPohon: 1, Tahun: 2024},
+		11: {IdPohon: 11, Tahun: 2025, CloneFrom: 1},
+		21: {IdPohon: 21, Tahun: 2026, CloneFrom: 11},
+		// pokin OPD hasil clone di tahun yang sama bukan riwayat antar tahun
+		99: {IdPohon: 99, Tahun: 2026, CloneFrom: 21},
+		// sumber di luar rentang yang dimuat
+		30: {IdPohon: 30, Tahun: 2026, CloneFrom: 500},
+		// clone_from yang menunjuk tahun lebih baru diabaikan
+		40: {IdPohon: 40, Tahun: 2025, CloneFrom: 21},
+	}
+	tests := []struct {
+		id, tahun int
+		want      int
+		ok        bool
+	}{
+		{21, 2024, 1, true},
+		{21, 2025, 11, true},
+		{21, 2026, 21, true},
+		{11, 2026, 0, false},
+		{99, 2024, 0, false},
+		{30, 2024, 0, false},
+		{40, 2026, 0, false},
+		{12345, 2024, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := lin.ancestorIn(tt.id, tt.tahun)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ancestorIn(%d, %d) = %d, %v, want %d, %v", tt.id, tt.tahun, got, ok, tt.want, tt.ok)
+		}
+	}
+}
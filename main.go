@@ -8,7 +8,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -16,41 +15,34 @@ import (
 
 var db *sql.DB
 
-func initDB() {
-	dsn := os.Getenv("PERENCANAAN_DB_URL")
-	if dsn == "" {
-		log.Fatal("PERENCANAAN_DB_URL env tidak terdefinisi")
-	}
-
+func initDB(ctx context.Context, dbCfg DBConfig) {
 	var err error
-	db, err = sql.Open("mysql", dsn)
+	db, err = sql.Open("mysql", dbCfg.URL)
 	if err != nil {
 		log.Fatalf("[FATAL] Error connecting to db: %v", err)
 	}
 
-	log.Printf("koneksi ke database berhasil")
-	db.SetMaxOpenConns(90)
-	db.SetMaxIdleConns(45)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-	db.SetConnMaxLifetime(60 * time.Minute)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	db.SetMaxOpenConns(dbCfg.MaxOpenConns)
+	db.SetMaxIdleConns(dbCfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(time.Duration(dbCfg.ConnMaxIdleTime))
+	db.SetConnMaxLifetime(time.Duration(dbCfg.ConnMaxLifetime))
 
-	err = db.PingContext(ctx)
-	if err != nil {
-		log.Printf("Gagal terhubung ke database dalam 10 detik: %v", err)
-		log.Printf("Mencoba koneksi ulang...")
+	if dbCfg.StartNotReady {
+		// server tetap jalan, readiness 503 sampai monitorDB berhasil konek
+		if err := pingDB(ctx, time.Duration(dbCfg.PingTimeout)); err != nil {
+			log.Printf("[WARN] database belum siap, server berjalan dalam mode not-ready: %v", err)
+		} else {
+			dbReady.Store(true)
+		}
+	} else if err := connectDB(ctx, dbCfg, dbCfg.StartupMaxAttempts); err != nil {
+		db.Close()
+		log.Fatalf("Koneksi database gagal setelah %d percobaan: %v", dbCfg.StartupMaxAttempts, err)
+	}
 
-		// Coba lagi dengan timeout yang lebih lama
-		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	go monitorDB(ctx, dbCfg)
 
-		err = db.PingContext(ctx)
-		if err != nil {
-			db.Close()
-			log.Fatalf("Koneksi database gagal setelah percobaan ulang: %v", err)
-		}
+	if !dbReady.Load() {
+		return
 	}
 
 	log.Print("Berhasil terhubung ke database")
@@ -60,8 +52,8 @@ func initDB() {
 	log.Printf("Idle Connections: %d", db.Stats().Idle)
 }
 
-func getSasaranPemda(idPokin int) ([]SasaranPemda, error) {
-	rows, err := db.Query(`SELECT sas.id, sas.subtema_id, sas.sasaran_pemda, sas.periode_id, per.tahun_awal, per.tahun_akhir, per.jenis_periode
+func getSasaranPemda(ctx context.Context, idPokin int) ([]SasaranPemda, error) {
+	rows, err := queryRetry(ctx, `SELECT sas.id, sas.subtema_id, sas.sasaran_pemda, sas.periode_id, per.tahun_awal, per.tahun_akhir, per.jenis_periode
                            FROM tb_sasaran_pemda sas
 						   JOIN tb_periode per ON per.id = sas.periode_id
 						   WHERE sas.subtema_id = ?`, idPokin)
@@ -83,8 +75,8 @@ func getSasaranPemda(idPokin int) ([]SasaranPemda, error) {
 	return sasaranPemdas, nil
 }
 
-func getTujuanPemda(idPokin int) ([]TujuanPemda, error) {
-	rows, err := db.Query(`SELECT tuj.id, tuj.tujuan_pemda, tuj.tematik_id, tuj.periode_id, per.tahun_awal, per.tahun_akhir, per.jenis_periode
+func getTujuanPemda(ctx context.Context, idPokin int) ([]TujuanPemda, error) {
+	rows, err := queryRetry(ctx, `SELECT tuj.id, tuj.tujuan_pemda, tuj.tematik_id, tuj.periode_id, per.tahun_awal, per.tahun_akhir, per.jenis_periode
 						   FROM tb_tujuan_pemda tuj
 						   JOIN tb_periode per ON per.id = tuj.periode_id
 						   WHERE tuj.tematik_id = ?`, idPokin)
@@ -108,8 +100,8 @@ func getTujuanPemda(idPokin int) ([]TujuanPemda, error) {
 	return tujuans, nil
 }
 
-func getTaggingPokin(idPokin int) ([]TaggingPokin, error) {
-	rows, err := db.Query(`SELECT id, id_pokin, nama_tagging, keterangan_tagging, clone_from
+func getTaggingPokin(ctx context.Context, idPokin int) ([]TaggingPokin, error) {
+	rows, err := queryRetry(ctx, `SELECT id, id_pokin, nama_tagging, keterangan_tagging, clone_from
                            FROM tb_tagging_pokin
                            WHERE id_pokin = ?`, idPokin)
 	if err != nil {
@@ -130,9 +122,12 @@ func getTaggingPokin(idPokin int) ([]TaggingPokin, error) {
 	return tags, nil
 }
 
-func getUrusan(kodeBidangUrusan string) (Urusan, error) {
+func getUrusan(ctx context.Context, kodeBidangUrusan string) (Urusan, error) {
 	var kodeUrusan = kodeBidangUrusan[:1]
-	rows, err := db.Query(`SELECT kode_urusan, nama_urusan FROM tb_urusan WHERE kode_urusan = ?`, kodeUrusan)
+	if urs, ok := urusanCache.get(kodeUrusan); ok {
+		return urs, nil
+	}
+	rows, err := queryRetry(ctx, `SELECT kode_urusan, nama_urusan FROM tb_urusan WHERE kode_urusan = ?`, kodeUrusan)
 	if err != nil {
 		return Urusan{}, fmt.Errorf("query error: %w", err)
 	}
@@ -147,12 +142,21 @@ func getUrusan(kodeBidangUrusan string) (Urusan, error) {
 			return Urusan{}, fmt.Errorf("query error: %w", err)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return Urusan{}, fmt.Errorf("rows error: %w", err)
+	}
+	if urs.KodeUrusan != "" {
+		urusanCache.set(kodeUrusan, urs)
+	}
 	return urs, nil
 }
 
-func getBidangUrusan(kodeProgram string) (BidangUrusan, error) {
+func getBidangUrusan(ctx context.Context, kodeProgram string) (BidangUrusan, error) {
 	var kodeBidangUrusan = kodeProgram[:4]
-	rows, err := db.Query(`SELECT kode_bidang_urusan, nama_bidang_urusan FROM tb_bidang_urusan WHERE kode_bidang_urusan = ?`, kodeBidangUrusan)
+	if bidUr, ok := bidangUrusanCache.get(kodeBidangUrusan); ok {
+		return bidUr, nil
+	}
+	rows, err := queryRetry(ctx, `SELECT kode_bidang_urusan, nama_bidang_urusan FROM tb_bidang_urusan WHERE kode_bidang_urusan = ?`, kodeBidangUrusan)
 	if err != nil {
 		return BidangUrusan{}, fmt.Errorf("query error: %w", err)
 	}
@@ -167,12 +171,21 @@ func getBidangUrusan(kodeProgram string) (BidangUrusan, error) {
 			return BidangUrusan{}, fmt.Errorf("query error: %w", err)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return BidangUrusan{}, fmt.Errorf("rows error: %w", err)
+	}
+	if bidUr.KodeBidangUrusan != "" {
+		bidangUrusanCache.set(kodeBidangUrusan, bidUr)
+	}
 	return bidUr, nil
 }
 
-func getProgramFromKegiatan(kodeKegiatan string) (Program, error) {
+func getProgramFromKegiatan(ctx context.Context, kodeKegiatan string) (Program, error) {
 	var kodeProgram = kodeKegiatan[:7]
-	rows, err := db.Query(`SELECT kode_program, nama_program FROM tb_master_program WHERE kode_program = ?`, kodeProgram)
+	if prog, ok := programCache.get(kodeProgram); ok {
+		return prog, nil
+	}
+	rows, err := queryRetry(ctx, `SELECT kode_program, nama_program FROM tb_master_program WHERE kode_program = ?`, kodeProgram)
 	if err != nil {
 		return Program{}, fmt.Errorf("query error: %w", err)
 	}
@@ -187,12 +200,21 @@ func getProgramFromKegiatan(kodeKegiatan string) (Program, error) {
 			return Program{}, fmt.Errorf("query error: %w", err)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return Program{}, fmt.Errorf("rows error: %w", err)
+	}
+	if prog.KodeProgram != "" {
+		programCache.set(kodeProgram, prog)
+	}
 	return prog, nil
 }
 
-func getKegiatanFromSubkegiatan(kodeSubkegiatan string) (Kegiatan, error) {
+func getKegiatanFromSubkegiatan(ctx context.Context, kodeSubkegiatan string) (Kegiatan, error) {
 	var kodeKegiatan = kodeSubkegiatan[:12] // substring kode subkegiatan
-	rows, err := db.Query(`SELECT kode_kegiatan, nama_kegiatan FROM tb_master_kegiatan WHERE kode_kegiatan = ?`, kodeKegiatan)
+	if keg, ok := kegiatanCache.get(kodeKegiatan); ok {
+		return keg, nil
+	}
+	rows, err := queryRetry(ctx, `SELECT kode_kegiatan, nama_kegiatan FROM tb_master_kegiatan WHERE kode_kegiatan = ?`, kodeKegiatan)
 	if err != nil {
 		return Kegiatan{}, fmt.Errorf("query error: %w", err)
 	}
@@ -208,10 +230,16 @@ func getKegiatanFromSubkegiatan(kodeSubkegiatan string) (Kegiatan, error) {
 		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return Kegiatan{}, fmt.Errorf("rows error: %w", err)
+	}
+	if keg.KodeKegiatan != "" {
+		kegiatanCache.set(kodeKegiatan, keg)
+	}
 	return keg, nil
 }
 
-func getRencanaKinerjaPokin(idPokin int, tahun int) ([]RencanaKinerjaAsn, error) {
+func getRencanaKinerjaPokin(ctx context.Context, idPokin int, tahun int, opts BuildOptions) ([]RencanaKinerjaAsn, error) {
 	query := `
 		SELECT rekin.id,
 		       rekin.nama_rencana_kinerja,
@@ -240,9 +268,10 @@ func getRencanaKinerjaPokin(idPokin int, tahun int) ([]RencanaKinerjaAsn, error)
 		         pegawai.nama, pegawai.nip,
 		         keg.kode_kegiatan, keg.nama_kegiatan,
 		         subkegiatan.kode_subkegiatan, subkegiatan.nama_subkegiatan
+		ORDER BY rekin.id, subkegiatan.kode_subkegiatan
 	`
 
-	rows, err := db.Query(query, idPokin)
+	rows, err := queryRetry(ctx, query, idPokin)
 	if err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
@@ -287,19 +316,21 @@ func getRencanaKinerjaPokin(idPokin int, tahun int) ([]RencanaKinerjaAsn, error)
 			rekin.Pagu = Pagu(totalPagu.Int64)
 		}
 
-		// get indikator kegiatan
-		indKegs, err := getIndikatorsPKS(rekin.KodeKegiatan, tahun)
-		if err != nil {
-			return nil, fmt.Errorf("scan ind keg error: %w", err)
-		}
-		rekin.IndikatorKegiatan = indKegs
+		if opts.loads(partRekin) && opts.loads(partIndikator) {
+			// get indikator kegiatan
+			indKegs, err := getIndikatorsPKS(ctx, rekin.KodeKegiatan, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, fmt.Errorf("scan ind keg error: %w", err)
+			}
+			rekin.IndikatorKegiatan = indKegs
 
-		// get indikator subkegiatan
-		indSubKegs, err := getIndikatorsPKS(rekin.KodeSubkegiatan, tahun)
-		if err != nil {
-			return nil, fmt.Errorf("scan ind sub error: %w", err)
+			// get indikator subkegiatan
+			indSubKegs, err := getIndikatorsPKS(ctx, rekin.KodeSubkegiatan, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, fmt.Errorf("scan ind sub error: %w", err)
+			}
+			rekin.IndikatorSubkegiatan = indSubKegs
 		}
-		rekin.IndikatorSubkegiatan = indSubKegs
 
 		rekins = append(rekins, rekin)
 	}
@@ -311,21 +342,23 @@ func getRencanaKinerjaPokin(idPokin int, tahun int) ([]RencanaKinerjaAsn, error)
 	return rekins, nil
 }
 
-func findPokinById(idPokin int, tahun int) (PohonKinerjaPemda, error) {
+func findPokinById(ctx context.Context, idPokin int, tahun int, opts BuildOptions) (PohonKinerjaPemda, error) {
 	query := `SELECT id, tahun, nama_pohon, kode_opd, jenis_pohon, keterangan, status
 			  FROM tb_pohon_kinerja
 			  WHERE tahun = ? AND clone_from = ? LIMIT 1`
 
 	var pokin PohonKinerjaPemda
-	err := db.QueryRow(query, tahun, idPokin).Scan(
-		&pokin.IdPohon,
-		&pokin.Tahun,
-		&pokin.NamaPohon,
-		&pokin.KodeOpd,
-		&pokin.JenisPohon,
-		&pokin.Keterangan,
-		&pokin.Status,
-	)
+	err := withRetry(ctx, cfg.DB, func() error {
+		return db.QueryRowContext(ctx, query, tahun, idPokin).Scan(
+			&pokin.IdPohon,
+			&pokin.Tahun,
+			&pokin.NamaPohon,
+			&pokin.KodeOpd,
+			&pokin.JenisPohon,
+			&pokin.Keterangan,
+			&pokin.Status,
+		)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return PohonKinerjaPemda{}, nil
@@ -333,20 +366,23 @@ func findPokinById(idPokin int, tahun int) (PohonKinerjaPemda, error) {
 		return PohonKinerjaPemda{}, fmt.Errorf("query error: %w", err)
 	}
 
-	sasarans, err := getRencanaKinerjaPokin(pokin.IdPohon, tahun)
+	sasarans, err := getRencanaKinerjaPokin(ctx, pokin.IdPohon, tahun, opts)
 	if err != nil {
 		log.Printf("[ERROR] Get Rekin Pokin %d error: %v", idPokin, err)
 		return pokin, fmt.Errorf("getRencanaKinerjaPokin(%d): %w", pokin.IdPohon, err)
 	}
 
+	for i := range sasarans {
+		sasarans[i].KodeOpd = pokin.KodeOpd
+	}
 	pokin.RencanaKinerjas = sasarans
 
 	return pokin, nil
 }
 
-func getIndikatorsPKS(kode string, tahun int) ([]IndikatorPohon, error) {
+func getIndikatorsPKS(ctx context.Context, kode string, tahun int, withTarget bool) ([]IndikatorPohon, error) {
 	// TODO dyanimckan tahun
-	rows, err := db.Query(`SELECT id, indikator, kode FROM tb_indikator WHERE tahun = ? AND kode = ? `, tahun, kode)
+	rows, err := queryRetry(ctx, `SELECT id, indikator, kode FROM tb_indikator WHERE tahun = ? AND kode = ? `, tahun, kode)
 	if err != nil {
 		return nil, fmt.Errorf("query indikator error: %w", err)
 	}
@@ -361,57 +397,14 @@ func getIndikatorsPKS(kode string, tahun int) ([]IndikatorPohon, error) {
 		}
 
 		// ambil target
-		// TODO dyanimckan tahun
-		targetRows, err := db.Query(`
-			SELECT id, indikator_id, target, satuan, tahun
-			FROM tb_target
-			WHERE indikator_id = ?`, ind.IdIndikator)
-		if err != nil {
-			return nil, fmt.Errorf("query target error: %w", err)
-		}
-		defer targetRows.Close()
-
-		var tarPt []TargetIndikator
-		for targetRows.Next() {
-			var tar TargetIndikator
-
-			var target sql.NullString
-			var satuan sql.NullString
-			var tahun sql.NullInt64
-
-			if err := targetRows.Scan(
-				&tar.IdTarget,
-				&tar.IndikatorId,
-				&target,
-				&satuan,
-				&tahun,
-			); err != nil {
-				return nil, fmt.Errorf("scan target error: %w", err)
-			}
-
-			// handle NULL → set default kosong / nol
-			if target.Valid {
-				tar.Target = target.String
-			} else {
-				tar.Target = ""
-			}
-
-			if satuan.Valid {
-				tar.Satuan = satuan.String
-			} else {
-				tar.Satuan = ""
-			}
-
-			if tahun.Valid {
-				tar.Tahun = int(tahun.Int64)
-			} else {
-				tar.Tahun = 0
+		if withTarget {
+			tarPt, err := getTargetIndikator(ctx, ind.IdIndikator)
+			if err != nil {
+				return nil, err
 			}
-
-			tarPt = append(tarPt, tar)
+			ind.Target = tarPt
 		}
 
-		ind.Target = tarPt
 		indPt = append(indPt, ind)
 	}
 
@@ -422,8 +415,72 @@ func getIndikatorsPKS(kode string, tahun int) ([]IndikatorPohon, error) {
 	return indPt, nil
 }
 
-func getIndikators(idPokin int, tahun int) ([]IndikatorPohon, error) {
-	indTematikRows, err := db.Query(`SELECT id, pokin_id, indikator FROM tb_indikator WHERE tahun = ? AND pokin_id = ?`, tahun, idPokin)
+func getTargetIndikator(ctx context.Context, indikatorId string) ([]TargetIndikator, error) {
+	// TODO dyanimckan tahun
+	targetRows, err := queryRetry(ctx, `
+		SELECT id, indikator_id, target, satuan, tahun
+		FROM tb_target
+		WHERE indikator_id = ?
+		ORDER BY tahun`, indikatorId)
+	if err != nil {
+		return nil, fmt.Errorf("query target error: %w", err)
+	}
+	defer targetRows.Close()
+
+	var tarPt []TargetIndikator
+	for targetRows.Next() {
+		var tar TargetIndikator
+
+		var target sql.NullString
+		var satuan sql.NullString
+		var tahun sql.NullInt64
+
+		if err := targetRows.Scan(
+			&tar.IdTarget,
+			&tar.IndikatorId,
+			&target,
+			&satuan,
+			&tahun,
+		); err != nil {
+			return nil, fmt.Errorf("scan target error: %w", err)
+		}
+
+		// handle NULL → set default kosong / nol
+		if target.Valid {
+			tar.Target = target.String
+		} else {
+			tar.Target = ""
+		}
+
+		if satuan.Valid {
+			tar.Satuan = satuan.String
+		} else {
+			tar.Satuan = ""
+		}
+
+		if tahun.Valid {
+			tar.Tahun = int(tahun.Int64)
+		} else {
+			tar.Tahun = 0
+		}
+
+		parsed := parseAngka(tar.Target, tar.Satuan)
+		tar.Nilai = parsed.Nilai
+		tar.Persen = parsed.Persen
+		tar.Unit = parsed.Unit
+		tar.ParseStatus = parsed.Status
+
+		tarPt = append(tarPt, tar)
+	}
+	if err := targetRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return tarPt, nil
+}
+
+func getIndikators(ctx context.Context, idPokin int, tahun int, withTarget bool) ([]IndikatorPohon, error) {
+	indTematikRows, err := queryRetry(ctx, `SELECT id, pokin_id, indikator FROM tb_indikator WHERE tahun = ? AND pokin_id = ?`, tahun, idPokin)
 	if err != nil {
 		return nil, fmt.Errorf("query error %v", err)
 	}
@@ -434,28 +491,22 @@ func getIndikators(idPokin int, tahun int) ([]IndikatorPohon, error) {
 			return nil, fmt.Errorf("query error %v", err)
 		}
 		// targets
-		indTargetRows, err := db.Query(`SELECT id, indikator_id, target, satuan, tahun FROM tb_target WHERE indikator_id = ?`, ind.IdIndikator)
-		if err != nil {
-			return nil, fmt.Errorf("query error %v", err)
-		}
-		var tarPt []TargetIndikator
-		for indTargetRows.Next() {
-			var tar TargetIndikator
-			if err := indTargetRows.Scan(&tar.IdTarget, &tar.IndikatorId, &tar.Target, &tar.Satuan, &tar.Tahun); err != nil {
+		if withTarget {
+			tarPt, err := getTargetIndikator(ctx, ind.IdIndikator)
+			if err != nil {
 				return nil, fmt.Errorf("query error %v", err)
 			}
-			tarPt = append(tarPt, tar)
+			ind.Target = tarPt
 		}
 		// end targets
-		ind.Target = tarPt
 
 		indPt = append(indPt, ind)
 	}
 	return indPt, nil
 }
 
-func getChildPokins(parentId int, tahun int) ([]PohonKinerjaPemda, Pagu, error) {
-	rows, err := db.Query(`SELECT id, parent, tahun, nama_pohon, kode_opd, jenis_pohon, level_pohon, keterangan, status
+func getChildPokins(ctx context.Context, parentId int, tahun int, opts BuildOptions, level int) ([]PohonKinerjaPemda, Pagu, error) {
+	rows, err := queryRetry(ctx, `SELECT id, parent, tahun, nama_pohon, kode_opd, jenis_pohon, level_pohon, keterangan, status
 		FROM tb_pohon_kinerja
 		WHERE tahun = ? AND parent = ?`, tahun, parentId)
 	if err != nil {
@@ -465,6 +516,7 @@ func getChildPokins(parentId int, tahun int) ([]PohonKinerjaPemda, Pagu, error)
 
 	var childs []PohonKinerjaPemda
 	var totalPagu Pagu = 0
+	opts = opts.atLevel(level)
 
 	for rows.Next() {
 		var pt PohonKinerjaPemda
@@ -474,15 +526,17 @@ func getChildPokins(parentId int, tahun int) ([]PohonKinerjaPemda, Pagu, error)
 		}
 
 		// ambil indikator
-		indCt, err := getIndikators(pt.IdPohon, tahun)
-		if err != nil {
-			return nil, 0, err
+		if opts.loads(partIndikator) {
+			indCt, err := getIndikators(ctx, pt.IdPohon, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, 0, err
+			}
+			pt.Indikators = indCt
 		}
-		pt.Indikators = indCt
 
 		// operational pemda → ambil rencana kinerja langsung pakai IdPohon
-		if pt.Status == "disetujui" {
-			sourcePokin, err := findPokinById(pt.IdPohon, tahun)
+		if opts.Status.loadsRekin(pt.Status) {
+			sourcePokin, err := findPokinById(ctx, pt.IdPohon, tahun, opts)
 			if err != nil {
 				return nil, 0, fmt.Errorf("findPokinById(%d): %w", pt.IdPohon, err)
 			}
@@ -490,112 +544,48 @@ func getChildPokins(parentId int, tahun int) ([]PohonKinerjaPemda, Pagu, error)
 		}
 
 		// rekursif ambil anaknya
-		childTematiks, childPagu, err := getChildPokins(pt.IdPohon, tahun)
+		childTematiks, childPagu, err := getChildPokins(ctx, pt.IdPohon, tahun, opts, level+1)
 		if err != nil {
 			return nil, 0, err
 		}
 		pt.Childs = childTematiks
 
-		if pt.JenisPohon == "Tactical Pemda" && pt.Status == "disetujui" {
-			var programs []Program
-			seen := make(map[string]struct{})
-
-			for _, child := range pt.Childs {
-				for _, kegiatan := range child.RencanaKinerjas {
-					if kegiatan.KodeKegiatan == "" {
-						// skip kalau kode kosong
-						continue
-					}
-
-					programPokin, err := getProgramFromKegiatan(kegiatan.KodeKegiatan)
-					if err != nil {
-						// bisa log atau teruskan, sesuai kebutuhan
-						// log.Printf("Program tidak ditemukan untuk kode %s: %v", kegiatan.KodeKegiatan, err)
-						continue
-					}
-
-					if _, ok := seen[programPokin.KodeProgram]; !ok {
-						seen[programPokin.KodeProgram] = struct{}{}
-
-						// get indikator program
-						indList, err := getIndikatorsPKS(programPokin.KodeProgram, tahun)
-						if err != nil {
-							return nil, 0, fmt.Errorf("Indikator program error")
-						}
-
-						programPokin.IndikatorProgram = indList
-						programs = append(programs, programPokin)
-					}
-				}
-			}
-
-			pt.ProgramPokin = programs
+		// node yang tidak lolos filter status hanya tampil sebagai jalur ke
+		// turunan yang lolos, agregat dan pagu di bawah ini ikut tersaring
+		if !opts.Status.matches(pt.Status) && len(pt.Childs) == 0 {
+			continue
 		}
 
-		if pt.JenisPohon == "Strategic Pemda" && pt.Status == "disetujui" {
-			var bidangUrusans []BidangUrusan
-			seen := make(map[string]bool)
-
-			for _, child := range pt.Childs {
-				var programs = child.ProgramPokin
-				for _, program := range programs {
-					bidangUrusanPokin, err := getBidangUrusan(program.KodeProgram)
-					if err != nil {
-						return nil, 0, fmt.Errorf("Bidang Urusan tidak ditermukan")
-					}
-					if !seen[bidangUrusanPokin.KodeBidangUrusan] {
-						seen[bidangUrusanPokin.KodeBidangUrusan] = true
-						bidangUrusans = append(bidangUrusans, bidangUrusanPokin)
-					}
-				}
-			}
-			pt.BidangUrusanPokin = bidangUrusans
-		}
-
-		if pt.JenisPohon == "Sub Tematik" {
-			var bidangUrusans []BidangUrusan
-			seen := make(map[string]bool)
-
-			for _, child := range pt.Childs {
-				for _, bidangUrusanPokin := range child.BidangUrusanPokin {
-					if !seen[bidangUrusanPokin.KodeBidangUrusan] {
-						seen[bidangUrusanPokin.KodeBidangUrusan] = true
-						bidangUrusans = append(bidangUrusans, bidangUrusanPokin)
-					}
-				}
-			}
-
-			pt.BidangUrusanPokin = bidangUrusans
+		// program, bidang urusan, sasaran dst. mengikuti cfg.CascadingRules
+		if err := applyCascadingRules(ctx, &pt, tahun, opts); err != nil {
+			return nil, 0, err
 		}
 
-		if pt.JenisPohon == "Sub Tematik" || pt.JenisPohon == "Sub Sub Tematik" {
-			sasaranPemdas, err := getSasaranPemda(pt.IdPohon)
-			if err != nil {
-				return nil, 0, err
-			}
-			pt.SasaranPemda = sasaranPemdas
+		// hitung pagu node ini sendiri, setiap rekin sekali
+		nodePagu, err := paguRekin(pt.RencanaKinerjas)
+		if err == nil {
+			// tambahkan pagu anak
+			nodePagu, err = nodePagu.Add(childPagu)
 		}
-
-		// hitung pagu node ini sendiri
-		var nodePagu Pagu = 0
-		for _, rekin := range pt.RencanaKinerjas {
-			nodePagu += rekin.Pagu
+		if err != nil {
+			return nil, 0, fmt.Errorf("pagu pohon %d: %w", pt.IdPohon, err)
 		}
 
-		// tambahkan pagu anak
-		nodePagu += childPagu
-
 		// set pagu node ini sendiri
 		pt.Pagu = nodePagu
 
 		// tambahkan ke total pagu parent
-		totalPagu += nodePagu
+		if totalPagu, err = totalPagu.Add(nodePagu); err != nil {
+			return nil, 0, fmt.Errorf("pagu pohon %d: %w", parentId, err)
+		}
 
-		tagList, err := getTaggingPokin(pt.IdPohon)
-		if err != nil {
-			return nil, 0, err
+		if opts.loads(partTagging) {
+			tagList, err := getTaggingPokin(ctx, pt.IdPohon)
+			if err != nil {
+				return nil, 0, err
+			}
+			pt.Tagging = tagList
 		}
-		pt.Tagging = tagList
 
 		childs = append(childs, pt)
 	}
@@ -603,44 +593,15 @@ func getChildPokins(parentId int, tahun int) ([]PohonKinerjaPemda, Pagu, error)
 	return childs, totalPagu, nil
 }
 
-func cascadingHandler(w http.ResponseWriter, r *http.Request) {
-	// hanya terima GET method
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed, pakai GET", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// parameter for tematik
-	tematikIdStr := r.URL.Query().Get("tematikId")
-	if tematikIdStr == "" {
-		http.Error(w, "params tematikId is required, misal: ?tematikId=123", http.StatusBadRequest)
-		return
-	}
-
-	tahunStr := r.URL.Query().Get("tahun")
-	if tahunStr == "" {
-		http.Error(w, "params tahun is required, misal: ?tematikId=123&tahun=2025", http.StatusBadRequest)
-		return
-	}
-
-	tematikId, err := strconv.Atoi(tematikIdStr)
-	if err != nil {
-		http.Error(w, "invalid tematikId", http.StatusBadRequest)
-		return
-	}
-
-	tahun, err := strconv.Atoi(tahunStr)
-	if err != nil {
-		http.Error(w, "invalid tahun", http.StatusBadRequest)
-		return
-	}
-
+// buildTematik membangun pohon cascading satu Tematik beserta seluruh turunannya.
+// Hasil kosong berarti tematik tidak ditemukan untuk tahun tersebut.
+func buildTematik(ctx context.Context, tematikId int, tahun int, opts BuildOptions) ([]PohonKinerjaPemda, error) {
 	// query pohon tematik
-	rows, err := db.Query(`SELECT id, tahun, nama_pohon, kode_opd, jenis_pohon, level_pohon, keterangan,  status
+	rows, err := queryRetry(ctx, `SELECT id, tahun, nama_pohon, kode_opd, jenis_pohon, level_pohon, keterangan,  status
                            FROM tb_pohon_kinerja
                            WHERE level_pohon = 0 AND parent = 0 AND tahun = ? AND jenis_pohon = 'Tematik' AND id = ? LIMIT 1`, tahun, tematikId)
 	if err != nil {
-		http.Error(w, "query error: "+err.Error(), http.StatusInternalServerError)
+		return nil, fmt.Errorf("query error: %w", err)
 	}
 	defer rows.Close()
 
@@ -649,113 +610,239 @@ func cascadingHandler(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var pt PohonKinerjaPemda
 		if err := rows.Scan(&pt.IdPohon, &pt.Tahun, &pt.NamaPohon, &pt.KodeOpd, &pt.JenisPohon, &pt.LevelPohon, &pt.Keterangan, &pt.Status); err != nil {
-			http.Error(w, "scan error: "+err.Error(), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("scan error: %w", err)
 		}
 
-		indList, err := getIndikators(pt.IdPohon, tahun)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		if opts.loads(partIndikator) {
+			indList, err := getIndikators(ctx, pt.IdPohon, tahun, opts.loads(partTarget))
+			if err != nil {
+				return nil, err
+			}
+			pt.Indikators = indList
 		}
-		pt.Indikators = indList
 
-		childs, pagu, err := getChildPokins(pt.IdPohon, tahun)
+		// tematik = level 1, anaknya level 2
+		childs, pagu, err := getChildPokins(ctx, pt.IdPohon, tahun, opts, 2)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return nil, err
+		}
+		if totalPagu, err = totalPagu.Add(pagu); err != nil {
+			return nil, fmt.Errorf("pagu tematik %d: %w", pt.IdPohon, err)
 		}
-		totalPagu += pagu
 
 		pt.Childs = childs
 		pt.Pagu = totalPagu
 
-		// get urusan for tematik
-		var urusans []Urusan
-		seen := make(map[string]bool)
-
-		for _, child := range pt.Childs {
-			var bidangUrusans = child.BidangUrusanPokin
-			for _, bidangUrusan := range bidangUrusans {
-				urusanPokin, err := getUrusan(bidangUrusan.KodeBidangUrusan)
-				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
-					return
-				}
-				if !seen[urusanPokin.KodeUrusan] {
-					seen[urusanPokin.KodeUrusan] = true
-					urusans = append(urusans, urusanPokin)
+		// urusan tematik mengikuti cfg.CascadingRules
+		if err := applyCascadingRules(ctx, &pt, tahun, opts); err != nil {
+			return nil, err
+		}
+
+		if opts.loads(partTagging) {
+			tagList, err := getTaggingPokin(ctx, pt.IdPohon)
+			if err != nil {
+				return nil, err
+			}
+			pt.Tagging = tagList
+		}
+
+		if opts.loads(partTujuan) {
+			var uniqTujPemda []TujuanPemda
+			seenTuj := make(map[string]bool)
+
+			tujuanPemdas, err := getTujuanPemda(ctx, pt.IdPohon)
+			if err != nil {
+				return nil, err
+			}
+			for _, tuj := range tujuanPemdas {
+				if !seenTuj[tuj.TujuanPemda] {
+					seenTuj[tuj.TujuanPemda] = true
+					uniqTujPemda = append(uniqTujPemda, tuj)
 				}
 			}
+			pt.TujuanPemda = uniqTujPemda
 		}
-		pt.UrusanPokin = urusans
-		// end get urusans
 
-		tagList, err := getTaggingPokin(pt.IdPohon)
+		list = append(list, pt)
+	}
+
+	if opts.InheritTagging && opts.loads(partTagging) {
+		inheritTagging(list, nil)
+	}
+	opts.prune(list, 1)
+
+	return list, nil
+}
+
+func cascadingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// hanya terima GET method
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed, pakai GET")
+		return
+	}
+
+	tematikId, tahun, err := tematikTahunParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := parseBuildOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var fields FieldSet
+	if v := r.URL.Query().Get("fields"); v != "" {
+		fields, err = parseFieldSet(v)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		pt.Tagging = tagList
+	}
 
-		var uniqTujPemda []TujuanPemda
-		seenTuj := make(map[string]bool)
+	list, err := buildTematik(ctx, tematikId, tahun, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-		tujuanPemdas, err := getTujuanPemda(pt.IdPohon)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	// role OPD hanya melihat cabang milik OPD-nya
+	if kodeOpd := restrictedOpd(r.Context()); kodeOpd != "" {
+		if list, _, err = restrictTreeToOpd(list, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-		for _, tuj := range tujuanPemdas {
-			if !seenTuj[tuj.TujuanPemda] {
-				seenTuj[tuj.TujuanPemda] = true
-				uniqTujPemda = append(uniqTujPemda, tuj)
-			}
-		}
-		pt.TujuanPemda = uniqTujPemda
-
-		list = append(list, pt)
 	}
 
 	msg := fmt.Sprintf("Laporan Cascading Pemda Tahun %d", tahun)
 
+	w.Header().Set("Content-Type", "application/json")
+
+	if rupiah := formatPaguFrom(ctx); fields != nil || rupiah {
+		json.NewEncoder(w).Encode(SparseResponse{
+			Status:  http.StatusOK,
+			Message: msg,
+			Data:    projector{fields: fields, rupiah: rupiah}.project(list)})
+		return
+	}
+
 	response := CascadingPemda{
 		Status:  http.StatusOK,
 		Message: msg,
 		Tematik: list}
 
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// laporanRoute adalah satu endpoint laporan beserta sumber tahunnya.
+type laporanRoute struct {
+	path    string
+	tahun   tahunScope
+	handler http.Handler
+}
+
+// laporanRoutes mendaftar seluruh endpoint laporan. Dipanggil setelah
+// builds diisi karena endpoint yang membangun satu pohon mengambil slot
+// build di depan.
+func laporanRoutes() []laporanRoute {
+	// satu request membangun satu pohon, slot build diambil di depan
+	tree := func(h http.HandlerFunc) http.Handler {
+		if builds == nil {
+			return h
+		}
+		return builds.middleware(h)
+	}
+
+	return []laporanRoute{
+		{"/laporan/cascading_pemda", tahunQuery("tahun"), tree(cascadingHandler)},
+		// batch mengambil slot build per tematik
+		{"/laporan/cascading_pemda/batch", tahunDiHandler, http.HandlerFunc(cascadingBatchHandler)},
+		// pencarian membaca seluruh pohon satu tahun, jadi ikut antre slot build
+		{"/laporan/cascading_pemda/search", tahunQuery("tahun"), tree(searchHandler)},
+		{"/laporan/cascading_pemda/kode", tahunQuery("tahun"), http.HandlerFunc(kodeLookupHandler)},
+		{"/laporan/cascading_pemda/pegawai", tahunQuery("tahun"), http.HandlerFunc(pegawaiHandler)},
+		// laporan tagging mengambil slot build per tematik, seperti batch
+		{"/laporan/cascading_pemda/tagging", tahunQuery("tahun"), http.HandlerFunc(taggingHandler)},
+		{"/laporan/cascading_pemda/diff", tahunQuery("tahun_a", "tahun_b"), tree(diffHandler)},
+		// lineage membangun pagu setiap versi, slot build diambil per versi
+		{"/laporan/cascading_pemda/lineage", tahunDiHandler, http.HandlerFunc(lineageHandler)},
+		{"/laporan/cascading_pemda/target_matrix", tahunQuery("tahun"), tree(targetMatrixHandler)},
+		{"/laporan/cascading_pemda/capaian", tahunQuery("tahun"), tree(capaianHandler)},
+		{"/laporan/cascading_pemda/pagu", tahunQuery("tahun"), tree(paguBreakdownHandler)},
+		// rekonsiliasi bisa membangun semua tematik, slot build diambil per tematik
+		{"/laporan/cascading_pemda/pagu/rekonsiliasi", tahunQuery("tahun"), http.HandlerFunc(reconcileHandler)},
+	}
+}
+
+// laporanHandler menyusun rantai endpoint laporan:
+// auth → rate limit → scope tahun → cek database → handler.
+func laporanHandler(rt laporanRoute, authenticate func(http.Handler) http.Handler, limiter *rateLimiter) http.Handler {
+	if !rt.tahun.declared() {
+		panic(fmt.Sprintf("endpoint %s belum menyatakan sumber tahun", rt.path))
+	}
+	handler := rt.tahun.middleware(requireDB(checkFormatPagu(rt.handler)))
+	if cfg.Limit.Enabled {
+		handler = limiter.middleware(rt.path, handler)
+	}
+	return authenticate(handler)
+}
+
 func main() {
 	log.Print("CASCADING PEMDA 2025")
 
-	initDB()
+	var err error
+	cfg, err = loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("[FATAL] konfigurasi tidak valid: %v", err)
+	}
+	log.Printf("Konfigurasi: %s", cfg)
 
-	http.HandleFunc("/laporan/cascading_pemda", cascadingHandler)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	handler := corsMiddleware(http.DefaultServeMux)
+	var apiKeys *apiKeyStore
+	if cfg.Auth.APIKeysFile != "" {
+		apiKeys, err = loadAPIKeyStore(cfg.Auth.APIKeysFile)
+		if err != nil {
+			log.Fatalf("[FATAL] api keys tidak valid: %v", err)
+		}
+		go apiKeys.watch(ctx, time.Duration(cfg.Auth.APIKeyReloadInterval))
+	}
 
-	log.Println("Server running di :8080")
+	authenticate, err := newAuthMiddleware(cfg.Auth, apiKeys)
+	if err != nil {
+		log.Fatalf("[FATAL] konfigurasi auth tidak valid: %v", err)
+	}
 
-	http.ListenAndServe(":8080", handler)
-}
+	initDB(ctx, cfg.DB)
 
-// Middleware CORS
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Untuk development, bisa pakai "*"
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-
-		// Preflight request (OPTIONS)
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	limiter := newRateLimiter(cfg.Limit)
+	if cfg.Limit.Enabled {
+		go limiter.sweep(ctx)
+	}
+	builds = newBuildLimiter(cfg.Limit)
 
-		next.ServeHTTP(w, r)
-	})
+	initMasterCache(time.Duration(cfg.MasterCacheTTL))
+
+	http.HandleFunc("/health/live", liveHandler)
+	http.HandleFunc("/health/ready", readyHandler)
+	for _, rt := range laporanRoutes() {
+		http.Handle(rt.path, laporanHandler(rt, authenticate, limiter))
+	}
+	if apiKeys != nil {
+		http.Handle("/admin/api_keys/usage", authenticate(http.HandlerFunc(apiKeys.apiKeyUsageHandler)))
+	}
+
+	stats := &requestStats{}
+	handler := stats.middleware(corsMiddleware(cfg.CORS, http.DefaultServeMux))
+
+	srv := newServer(cfg, handler)
+	err = runServer(srv, stats, time.Duration(cfg.Server.ShutdownTimeout))
+	cancel()
+	if err != nil {
+		log.Fatalf("[FATAL] server error: %v", err)
+	}
 }
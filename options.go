@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// bagian pohon yang bisa dipilih lewat ?include= dan ?exclude=
+const (
+	partIndikator    = "indikator"
+	partTarget       = "target"
+	partRekin        = "rekin"
+	partTagging      = "tagging"
+	partSasaran      = "sasaran"
+	partTujuan       = "tujuan"
+	partProgram      = "program"
+	partBidangUrusan = "bidang_urusan"
+	partUrusan       = "urusan"
+	partKegiatan     = "kegiatan"
+	partSubkegiatan  = "subkegiatan"
+)
+
+var allParts = []string{
+	partIndikator, partTarget, partRekin, partTagging, partSasaran,
+	partTujuan, partProgram, partBidangUrusan, partUrusan,
+	partKegiatan, partSubkegiatan,
+}
+
+// BuildOptions mengatur apa saja yang dimuat tree builder.
+// Nilai default memuat semuanya, sama dengan output sebelum ada opsi.
+type BuildOptions struct {
+	// Depth membatasi jumlah level yang ditampilkan, Tematik = level 1. 0 berarti semua.
+	Depth int
+	parts map[string]bool
+	// hidden menandai level di bawah Depth: hanya dimuat yang dibutuhkan
+	// agregat level di atasnya (pagu, program, bidang urusan).
+	hidden bool
+
+	// Status menyaring node berdasarkan status pohon, kosong berarti semua.
+	// Node yang tidak lolos tetap tampil bila punya turunan yang lolos, tanpa
+	// rekin. Tematik selalu tampil sebagai akar laporan.
+	Status StatusFilter
+	// ShowStatus menampilkan status tiap node di JSON.
+	ShowStatus bool
+
+	// InheritTagging menurunkan tagging node ke seluruh turunannya.
+	InheritTagging bool
+}
+
+const statusDisetujui = "disetujui"
+
+// StatusFilter berisi daftar status yang ditampilkan. "pending" berarti
+// semua status selain disetujui, "all" atau kosong berarti tanpa filter.
+type StatusFilter []string
+
+func parseStatusFilter(v string) StatusFilter {
+	var f StatusFilter
+	for _, s := range splitList(strings.ToLower(v)) {
+		if s == "all" {
+			return nil
+		}
+		f = append(f, s)
+	}
+	return f
+}
+
+func (f StatusFilter) matches(status string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	status = strings.ToLower(status)
+	for _, want := range f {
+		if want == status || (want == "pending" && status != statusDisetujui) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadsRekin menandai node yang rencana kinerjanya diambil: tanpa filter
+// hanya node disetujui, dengan filter setiap node yang lolos filter.
+func (f StatusFilter) loadsRekin(status string) bool {
+	if len(f) == 0 {
+		return status == statusDisetujui
+	}
+	return f.matches(status)
+}
+
+// inheritTagging menambahkan tagging leluhur ke setiap node dengan
+// penanda inherited_from. Tagging milik node sendiri didahulukan bila
+// namanya sama dengan tagging turunan.
+func inheritTagging(nodes []PohonKinerjaPemda, inherited []TaggingPokin) {
+	for i := range nodes {
+		n := &nodes[i]
+
+		own := make(map[string]bool, len(n.Tagging))
+		for _, tag := range n.Tagging {
+			own[strings.ToLower(strings.TrimSpace(tag.NamaTagging))] = true
+		}
+
+		// turunan untuk anak: tagging node ini lalu tagging leluhur yang belum ada
+		var next []TaggingPokin
+		for _, tag := range n.Tagging {
+			if tag.InheritedFrom == 0 {
+				tag.InheritedFrom = tag.IdPokin
+			}
+			next = append(next, tag)
+		}
+		for _, tag := range inherited {
+			if !own[strings.ToLower(strings.TrimSpace(tag.NamaTagging))] {
+				n.Tagging = append(n.Tagging, tag)
+				next = append(next, tag)
+			}
+		}
+
+		inheritTagging(n.Childs, next)
+	}
+}
+
+func defaultBuildOptions() BuildOptions {
+	parts := make(map[string]bool, len(allParts))
+	for _, p := range allParts {
+		parts[p] = true
+	}
+	return BuildOptions{parts: parts}
+}
+
+// optionsWithout adalah defaultBuildOptions tanpa bagian yang tidak dipakai
+// laporan, misal laporan pagu tidak perlu indikator dan master kode.
+func optionsWithout(parts ...string) BuildOptions {
+	opts := defaultBuildOptions()
+	for _, p := range parts {
+		delete(opts.parts, p)
+	}
+	return opts
+}
+
+// paguOnlyOptions membangun pohon hanya dengan rekin dan pagu, dipakai
+// laporan rincian pagu dan rekonsiliasi.
+func paguOnlyOptions() BuildOptions {
+	return optionsWithout(partIndikator, partTarget, partTagging, partSasaran, partTujuan,
+		partProgram, partBidangUrusan, partUrusan, partKegiatan, partSubkegiatan)
+}
+
+// paramInt membaca parameter angka wajib dari query string.
+func paramInt(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, fmt.Errorf("params %s is required", name)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return n, nil
+}
+
+// tematikTahunParams membaca ?tematikId= dan ?tahun= untuk laporan satu tematik.
+func tematikTahunParams(q url.Values) (tematikId, tahun int, err error) {
+	if tematikId, err = paramInt(q, "tematikId"); err == nil {
+		tahun, err = paramInt(q, "tahun")
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w, misal: ?tematikId=123&tahun=2025", err)
+	}
+	return tematikId, tahun, nil
+}
+
+// parseBuildOptions membaca ?depth=, ?status=, ?show_status=,
+// ?inherit_tagging=, ?include= dan ?exclude=. ?format_pagu= dibaca
+// checkFormatPagu.
+// include mengganti daftar default, exclude dikurangkan setelahnya.
+func parseBuildOptions(q url.Values) (BuildOptions, error) {
+	opts := defaultBuildOptions()
+
+	if v := q.Get("depth"); v != "" {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 1 {
+			return opts, fmt.Errorf("invalid depth, minimal 1")
+		}
+		opts.Depth = depth
+	}
+
+	opts.Status = parseStatusFilter(q.Get("status"))
+	if v := q.Get("show_status"); v != "" {
+		show, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid show_status, pakai true/false")
+		}
+		opts.ShowStatus = show
+	}
+
+	if v := q.Get("inherit_tagging"); v != "" {
+		inherit, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid inherit_tagging, pakai true/false")
+		}
+		opts.InheritTagging = inherit
+	}
+
+	if v := q.Get("include"); v != "" {
+		parts, err := parseParts(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.parts = make(map[string]bool, len(parts))
+		for _, p := range parts {
+			opts.parts[p] = true
+		}
+	}
+
+	if v := q.Get("exclude"); v != "" {
+		parts, err := parseParts(v)
+		if err != nil {
+			return opts, err
+		}
+		for _, p := range parts {
+			delete(opts.parts, p)
+		}
+	}
+
+	return opts, nil
+}
+
+func parseParts(v string) ([]string, error) {
+	parts := splitList(strings.ToLower(v))
+	for _, p := range parts {
+		if !slices.Contains(allParts, p) {
+			return nil, fmt.Errorf("bagian %q tidak dikenal, pilihan: %s", p, strings.Join(allParts, ","))
+		}
+	}
+	return parts, nil
+}
+
+// shows menandai bagian yang tampil di output.
+func (o BuildOptions) shows(part string) bool {
+	return o.parts[part]
+}
+
+// loads menandai bagian yang perlu di-query, termasuk yang tidak tampil
+// tetapi dibutuhkan untuk agregat: urusan ← bidang urusan ← program.
+func (o BuildOptions) loads(part string) bool {
+	switch part {
+	case partProgram:
+		return o.parts[partProgram] || o.loads(partBidangUrusan)
+	case partBidangUrusan:
+		return o.parts[partBidangUrusan] || o.parts[partUrusan]
+	case partUrusan:
+		return o.parts[partUrusan]
+	}
+	if o.hidden {
+		return false
+	}
+	switch part {
+	case partTarget:
+		return o.parts[partTarget] && o.parts[partIndikator]
+	case partRekin:
+		// baris rekin selalu dibaca untuk pagu, ini untuk detail indikatornya
+		return o.parts[partRekin]
+	}
+	return o.parts[part]
+}
+
+// atLevel mengembalikan opsi untuk node di level tertentu.
+func (o BuildOptions) atLevel(level int) BuildOptions {
+	if o.Depth > 0 && level > o.Depth {
+		o.hidden = true
+	}
+	return o
+}
+
+// prune membuang bagian yang tidak diminta dan level di bawah Depth
+// setelah seluruh agregat selesai dihitung.
+func (o BuildOptions) prune(nodes []PohonKinerjaPemda, level int) {
+	for i := range nodes {
+		n := &nodes[i]
+
+		if !o.shows(partIndikator) {
+			n.Indikators = nil
+		}
+		if !o.shows(partRekin) {
+			n.RencanaKinerjas = nil
+		}
+		if !o.shows(partTagging) {
+			n.Tagging = nil
+		}
+		if !o.shows(partSasaran) {
+			n.SasaranPemda = nil
+		}
+		if !o.shows(partTujuan) {
+			n.TujuanPemda = nil
+		}
+		if !o.shows(partProgram) {
+			n.ProgramPokin = nil
+		}
+		if !o.shows(partBidangUrusan) {
+			n.BidangUrusanPokin = nil
+		}
+		if !o.shows(partUrusan) {
+			n.UrusanPokin = nil
+		}
+		if !o.shows(partKegiatan) {
+			n.KegiatanPokin = nil
+		}
+		if !o.shows(partSubkegiatan) {
+			n.SubkegiatanPokin = nil
+		}
+		if !o.ShowStatus {
+			n.Status = ""
+		}
+
+		if o.Depth > 0 && level >= o.Depth {
+			n.Childs = nil
+			continue
+		}
+		o.prune(n.Childs, level+1)
+	}
+}
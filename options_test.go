@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStatusFilterMatches(t *testing.T) {
+	tests := []struct {
+		filter string
+		status string
+		want   bool
+	}{
+		{"", "draft", true},
+		{"all", "draft", true},
+		{"disetujui", "disetujui", true},
+		{"disetujui", "Disetujui", true},
+		{"disetujui", "draft", false},
+		{"pending", "draft", true},
+		{"pending", "disetujui", false},
+		{"draft,ditolak", "ditolak", true},
+	}
+	for _, tt := range tests {
+		if got := parseStatusFilter(tt.filter).matches(tt.status); got != tt.want {
+			t.Errorf("status=%q matches(%q) = %v, want %v", tt.filter, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestStatusFilterLoadsRekin(t *testing.T) {
+	// tanpa filter perilaku lama: hanya node disetujui
+	if parseStatusFilter("").loadsRekin("draft") || !parseStatusFilter("").loadsRekin("disetujui") {
+		t.Error("tanpa filter hanya node disetujui yang rekin-nya diambil")
+	}
+	if !parseStatusFilter("pending").loadsRekin("draft") {
+		t.Error("status=pending harus mengambil rekin node pending")
+	}
+	if parseStatusFilter("pending").loadsRekin("disetujui") {
+		t.Error("status=pending tidak boleh mengambil rekin node disetujui")
+	}
+}
+
+func TestParseBuildOptionsInvalid(t *testing.T) {
+	for _, q := range []map[string][]string{
+		{"depth": {"0"}},
+		{"show_status": {"ya"}},
+		{"include": {"rekin,foo"}},
+	} {
+		if _, err := parseBuildOptions(q); err == nil {
+			t.Errorf("parseBuildOptions(%v) harus error", q)
+		}
+	}
+}
+
+func TestTematikTahunParams(t *testing.T) {
+	tests := []struct {
+		q       url.Values
+		wantErr string
+	}{
+		{url.Values{"tematikId": {"7"}, "tahun": {"2025"}}, ""},
+		{url.Values{"tahun": {"2025"}}, "params tematikId is required"},
+		{url.Values{"tematikId": {"7"}}, "params tahun is required"},
+		{url.Values{"tematikId": {"x"}, "tahun": {"2025"}}, "invalid tematikId"},
+		{url.Values{"tematikId": {"7"}, "tahun": {"dua"}}, "invalid tahun"},
+	}
+	for _, tt := range tests {
+		id, tahun, err := tematikTahunParams(tt.q)
+		if tt.wantErr == "" {
+			if err != nil || id != 7 || tahun != 2025 {
+				t.Errorf("tematikTahunParams(%v) = %d, %d, %v", tt.q, id, tahun, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "misal:") {
+			t.Errorf("tematikTahunParams(%v) err = %v, want %q", tt.q, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOptionsWithout(t *testing.T) {
+	opts := optionsWithout(partRekin, partTagging)
+	if opts.loads(partRekin) || opts.loads(partTagging) || !opts.loads(partIndikator) {
+		t.Errorf("parts = %v", opts.parts)
+	}
+	if !defaultBuildOptions().loads(partRekin) {
+		t.Error("optionsWithout mengubah default")
+	}
+}
+
+func TestInheritTagging(t *testing.T) {
+	tree := []PohonKinerjaPemda{{
+		IdPohon: 1,
+		Tagging: []TaggingPokin{{Id: 10, IdPokin: 1, NamaTagging: "Stunting"}},
+		Childs: []PohonKinerjaPemda{{
+			IdPohon: 2,
+			// tagging sendiri dengan nama sama menang atas turunan
+			Tagging: []TaggingPokin{{Id: 20, IdPokin: 2, NamaTagging: " stunting", KeteranganTagging: "milik sendiri"}},
+			Childs:  []PohonKinerjaPemda{{IdPohon: 3}},
+		}, {
+			IdPohon: 4,
+			Tagging: []TaggingPokin{{Id: 40, IdPokin: 4, NamaTagging: "Kemiskinan"}},
+			Childs:  []PohonKinerjaPemda{{IdPohon: 5}},
+		}},
+	}}
+	inheritTagging(tree, nil)
+
+	type tagKey struct {
+		id, inheritedFrom int
+	}
+	tags := func(n PohonKinerjaPemda) []tagKey {
+		var keys []tagKey
+		for _, tag := range n.Tagging {
+			keys = append(keys, tagKey{tag.Id, tag.InheritedFrom})
+		}
+		return keys
+	}
+	node2, node4 := tree[0].Childs[0], tree[0].Childs[1]
+	tests := []struct {
+		name string
+		node PohonKinerjaPemda
+		want []tagKey
+	}{
+		{"tematik", tree[0], []tagKey{{10, 0}}},
+		{"tagging sendiri", node2, []tagKey{{20, 0}}},
+		// cucu menerima tagging node 2, bukan tagging Tematik yang sama namanya
+		{"cucu node 2", node2.Childs[0], []tagKey{{20, 2}}},
+		{"tagging sendiri ditambah turunan", node4, []tagKey{{40, 0}, {10, 1}}},
+		{"cucu node 4", node4.Childs[0], []tagKey{{40, 4}, {10, 1}}},
+	}
+	for _, tt := range tests {
+		if got := tags(tt.node); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: tagging = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseBuildOptionsIncludeExclude(t *testing.T) {
+	opts, err := parseBuildOptions(map[string][]string{"include": {"Indikator, target,urusan"}, "exclude": {"target"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for part, want := range map[string]bool{partIndikator: true, partUrusan: true, partTarget: false, partRekin: false, partProgram: false} {
+		if got := opts.shows(part); got != want {
+			t.Errorf("shows(%s) = %v, want %v", part, got, want)
+		}
+	}
+	// urusan dihitung dari program, jadi program dan bidang urusan tetap di-query
+	if !opts.loads(partProgram) || !opts.loads(partBidangUrusan) || opts.loads(partTarget) {
+		t.Errorf("loads program %v bidang %v target %v", opts.loads(partProgram), opts.loads(partBidangUrusan), opts.loads(partTarget))
+	}
+
+	opts, err = parseBuildOptions(map[string][]string{"exclude": {"indikator"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.shows(partIndikator) || !opts.shows(partTarget) || opts.loads(partTarget) {
+		t.Error("exclude=indikator harus ikut mematikan query target")
+	}
+}
+
+func TestBuildOptionsAtLevel(t *testing.T) {
+	opts := defaultBuildOptions()
+	opts.Depth = 2
+
+	for level, hidden := range map[int]bool{1: false, 2: false, 3: true, 6: true} {
+		if got := opts.atLevel(level).hidden; got != hidden {
+			t.Errorf("atLevel(%d).hidden = %v, want %v", level, got, hidden)
+		}
+	}
+
+	// di bawah depth hanya agregat yang tetap dimuat
+	below := opts.atLevel(3)
+	for part, want := range map[string]bool{
+		partProgram: true, partBidangUrusan: true, partUrusan: true,
+		partIndikator: false, partTarget: false, partRekin: false, partTagging: false, partKegiatan: false,
+	} {
+		if got := below.loads(part); got != want {
+			t.Errorf("di bawah depth loads(%s) = %v, want %v", part, got, want)
+		}
+	}
+
+	opts.Depth = 0
+	if opts.atLevel(10).hidden {
+		t.Error("depth 0 berarti semua level tampil")
+	}
+}
+
+func TestBuildOptionsPrune(t *testing.T) {
+	tree := func() []PohonKinerjaPemda {
+		return []PohonKinerjaPemda{{
+			IdPohon: 1, Pagu: 300, Status: statusDisetujui,
+			Indikators: []IndikatorPohon{{IdIndikator: "I1"}},
+			Tagging:    []TaggingPokin{{NamaTagging: "Stunting"}},
+			Childs: []PohonKinerjaPemda{{
+				IdPohon: 2, Pagu: 300,
+				ProgramPokin:    []Program{{KodeProgram: "1.01.01"}},
+				RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R1", Pagu: 300}},
+				Childs:          []PohonKinerjaPemda{{IdPohon: 3, Pagu: 300}},
+			}},
+		}}
+	}
+
+	opts, err := parseBuildOptions(map[string][]string{"depth": {"2"}, "exclude": {"tagging,rekin"}, "show_status": {"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := tree()
+	opts.prune(nodes, 1)
+	root := nodes[0]
+	if len(root.Childs) != 1 || root.Childs[0].Childs != nil {
+		t.Fatalf("depth 2 harus memotong anak level 3: %+v", root)
+	}
+	child := root.Childs[0]
+	if root.Tagging != nil || child.RencanaKinerjas != nil {
+		t.Errorf("tagging dan rekin harus dibuang: %+v %+v", root.Tagging, child.RencanaKinerjas)
+	}
+	if len(root.Indikators) != 1 || len(child.ProgramPokin) != 1 || root.Status != statusDisetujui {
+		t.Errorf("bagian lain harus tetap: %+v", root)
+	}
+	// pagu sudah dihitung sebelum prune dan tidak ikut berubah
+	if root.Pagu != 300 || child.Pagu != 300 {
+		t.Errorf("pagu = %d %d, want 300", root.Pagu, child.Pagu)
+	}
+
+	nodes = tree()
+	defaultBuildOptions().prune(nodes, 1)
+	if nodes[0].Status != "" || nodes[0].Childs[0].Childs == nil || nodes[0].Childs[0].RencanaKinerjas == nil {
+		t.Errorf("default: status disembunyikan, level dan rekin lengkap: %+v", nodes[0])
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Pagu adalah nilai rupiah tanpa sen. Selalu 64-bit supaya total APBD
+// dalam triliunan tetap aman di build 32-bit.
+type Pagu int64
+
+var ErrPaguOverflow = errors.New("pagu melebihi batas int64")
+
+// Add menjumlah pagu dan mengembalikan ErrPaguOverflow bila hasilnya
+// keluar dari jangkauan int64.
+func (p Pagu) Add(q Pagu) (Pagu, error) {
+	sum := p + q
+	if (q > 0 && sum < p) || (q < 0 && sum > p) {
+		return p, fmt.Errorf("%w: %d + %d", ErrPaguOverflow, p, q)
+	}
+	return sum, nil
+}
+
+// Sub adalah Add untuk pengurangan, dipakai untuk selisih.
+func (p Pagu) Sub(q Pagu) (Pagu, error) {
+	diff := p - q
+	if (q > 0 && diff > p) || (q < 0 && diff < p) {
+		return p, fmt.Errorf("%w: %d - %d", ErrPaguOverflow, p, q)
+	}
+	return diff, nil
+}
+
+// Rupiah memformat pagu sebagai "Rp 1.234.567".
+func (p Pagu) Rupiah() string {
+	digits := strconv.FormatInt(int64(p), 10)
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+
+	out := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range len(digits) {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, digits[i])
+	}
+	return sign + "Rp " + string(out)
+}
+
+// paguSum menampung penjumlahan banyak pagu dan menyimpan overflow
+// pertama, supaya loop roll-up cukup memeriksa error sekali di akhir.
+type paguSum struct {
+	total Pagu
+	err   error
+}
+
+func (s *paguSum) add(p Pagu) {
+	if s.err != nil {
+		return
+	}
+	s.total, s.err = s.total.Add(p)
+}
+
+// paguRekin menjumlahkan pagu baris rekin dengan setiap rekin dihitung
+// sekali. Rincian belanja menempel di rekin, bukan di subkegiatan, jadi rekin
+// dengan beberapa subkegiatan muncul di beberapa baris dengan pagu penuh yang
+// sama. Semua laporan (pohon, tagging, rincian pagu, rekonsiliasi, kode dan
+// pegawai) memakai aturan ini supaya totalnya cocok satu sama lain.
+func paguRekin(rekins []RencanaKinerjaAsn) (Pagu, error) {
+	var sum paguSum
+	counted := make(map[string]bool)
+	for _, rekin := range rekins {
+		if counted[rekin.IdRekin] {
+			continue
+		}
+		counted[rekin.IdRekin] = true
+		sum.add(rekin.Pagu)
+	}
+	return sum.total, sum.err
+}
+
+// parseFormatPagu membaca ?format_pagu=. Bila true setiap properti Pagu
+// di respons mendapat pasangan <nama>_rupiah, lihat projector.
+func parseFormatPagu(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("format_pagu")
+	if v == "" {
+		return false, nil
+	}
+	format, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid format_pagu, pakai true/false")
+	}
+	return format, nil
+}
+
+type formatPaguKey struct{}
+
+// checkFormatPagu membaca ?format_pagu= sekali untuk semua laporan, menolak
+// nilai yang bukan true/false sebelum laporan dibangun dan menyimpan hasilnya
+// di context request, lihat formatPaguFrom.
+func checkFormatPagu(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format, err := parseFormatPagu(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ctx := context.WithValue(r.Context(), formatPaguKey{}, format)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// formatPaguFrom mengembalikan ?format_pagu= yang sudah dibaca checkFormatPagu.
+func formatPaguFrom(ctx context.Context) bool {
+	format, _ := ctx.Value(formatPaguKey{}).(bool)
+	return format
+}
+
+// writeLaporan menulis respons laporan 200, dengan <nama>_rupiah untuk
+// setiap pagu bila ?format_pagu=true.
+func writeLaporan(w http.ResponseWriter, r *http.Request, v any) {
+	if formatPaguFrom(r.Context()) {
+		v = projector{rupiah: true}.project(v)
+	}
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// kunci untuk rekin yang tidak punya kode OPD atau subkegiatan
+const tanpaKode = "-"
+
+type PaguShare struct {
+	Kode        string  `json:"kode"`
+	Nama        string  `json:"nama,omitempty"`
+	Pagu        Pagu    `json:"pagu"`
+	Persen      float64 `json:"persen"`
+	JumlahRekin int     `json:"jumlah_rekin"`
+}
+
+type PaguBreakdown struct {
+	Tahun           int         `json:"tahun"`
+	Tematik         PathNode    `json:"tematik"`
+	Total           Pagu        `json:"total"`
+	PerOpd          []PaguShare `json:"per_opd"`
+	PerUrusan       []PaguShare `json:"per_urusan"`
+	PerBidangUrusan []PaguShare `json:"per_bidang_urusan"`
+	PerProgram      []PaguShare `json:"per_program"`
+}
+
+type PaguBreakdownResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    PaguBreakdown `json:"data"`
+}
+
+type paguGroup map[string]*PaguShare
+
+func (g paguGroup) add(kode string, pagu Pagu) error {
+	s := g[kode]
+	if s == nil {
+		s = &PaguShare{Kode: kode}
+		g[kode] = s
+	}
+	sum, err := s.Pagu.Add(pagu)
+	if err != nil {
+		return fmt.Errorf("pagu %s: %w", kode, err)
+	}
+	s.Pagu = sum
+	s.JumlahRekin++
+	return nil
+}
+
+// shares mengurutkan kelompok dari pagu terbesar dan menghitung persennya.
+func (g paguGroup) shares(total Pagu, nama func(kode string) string) []PaguShare {
+	list := make([]PaguShare, 0, len(g))
+	for _, s := range g {
+		if total != 0 {
+			s.Persen = float64(s.Pagu) / float64(total) * 100
+		}
+		if s.Kode != tanpaKode && nama != nil {
+			s.Nama = nama(s.Kode)
+		}
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Pagu != list[j].Pagu {
+			return list[i].Pagu > list[j].Pagu
+		}
+		return list[i].Kode < list[j].Kode
+	})
+	return list
+}
+
+// kodePrefix memotong kode subkegiatan ke level di atasnya mengikuti
+// hierarki getUrusan (1), getBidangUrusan (4) dan getProgramFromKegiatan (7).
+func kodePrefix(kode string, n int) string {
+	if len(kode) < n {
+		return tanpaKode
+	}
+	return kode[:n]
+}
+
+// breakdownPagu membagi pagu rekin di seluruh pohon. Totalnya sama dengan
+// pagu Tematik karena pagu node juga dijumlah dari rekin yang sama.
+func breakdownPagu(nodes []PohonKinerjaPemda, opd, urusan, bidang, program paguGroup) (Pagu, error) {
+	var total paguSum
+	for _, n := range nodes {
+		// setiap rekin sekali seperti paguRekin, masuk ke kelompok baris
+		// pertamanya (subkegiatan terkecil)
+		counted := make(map[string]bool)
+		for _, rekin := range n.RencanaKinerjas {
+			if counted[rekin.IdRekin] {
+				continue
+			}
+			counted[rekin.IdRekin] = true
+			// OPD diambil dari pokin pemilik rekin, sama dengan laporan
+			// rekonsiliasi, bukan dari kode_opd node pemda
+			kodeOpd := rekin.KodeOpd
+			if kodeOpd == "" {
+				kodeOpd = tanpaKode
+			}
+			kode := rekin.KodeSubkegiatan
+			if kode == "" {
+				kode = rekin.KodeKegiatan
+			}
+			err := errors.Join(
+				opd.add(kodeOpd, rekin.Pagu),
+				urusan.add(kodePrefix(kode, 1), rekin.Pagu),
+				bidang.add(kodePrefix(kode, 4), rekin.Pagu),
+				program.add(kodePrefix(kode, kodeProgramLen), rekin.Pagu),
+			)
+			if err != nil {
+				return 0, err
+			}
+			total.add(rekin.Pagu)
+		}
+		childTotal, err := breakdownPagu(n.Childs, opd, urusan, bidang, program)
+		if err != nil {
+			return 0, err
+		}
+		total.add(childTotal)
+	}
+	if total.err != nil {
+		return 0, fmt.Errorf("total pagu: %w", total.err)
+	}
+	return total.total, nil
+}
+
+func paguBreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tematikId, tahun, err := tematikTahunParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// cukup baris rekin, tanpa indikator dan agregat master
+	list, err := buildTematik(ctx, tematikId, tahun, paguOnlyOptions())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(list) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("tematik %d tidak ditemukan untuk tahun %d", tematikId, tahun))
+		return
+	}
+	if kodeOpd := restrictedOpd(r.Context()); kodeOpd != "" {
+		if list, _, err = restrictTreeToOpd(list, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	opd, urusan, bidang, program := paguGroup{}, paguGroup{}, paguGroup{}, paguGroup{}
+	total, err := breakdownPagu(list, opd, urusan, bidang, program)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// nama diambil dari data master yang sama dengan cascading, error cukup dikosongkan
+	result := PaguBreakdown{
+		Tahun:  tahun,
+		Total:  total,
+		PerOpd: opd.shares(total, nil),
+		PerUrusan: urusan.shares(total, func(kode string) string {
+			urs, _ := getUrusan(ctx, kode)
+			return urs.NamaUrusan
+		}),
+		PerBidangUrusan: bidang.shares(total, func(kode string) string {
+			bid, _ := getBidangUrusan(ctx, kode)
+			return bid.NamaBidangUrusan
+		}),
+		PerProgram: program.shares(total, func(kode string) string {
+			prog, _ := getProgramFromKegiatan(ctx, kode)
+			return prog.NamaProgram
+		}),
+	}
+	if len(list) > 0 {
+		result.Tematik = PathNode{IdPohon: list[0].IdPohon, NamaPohon: list[0].NamaPohon, JenisPohon: list[0].JenisPohon}
+	}
+
+	writeLaporan(w, r, PaguBreakdownResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Rincian Pagu Tahun %d", tahun),
+		Data:    result,
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestKodePrefix(t *testing.T) {
+	tests := []struct {
+		kode string
+		n    int
+		want string
+	}{
+		{"1.01.01.2.01.0001", 1, "1"},
+		{"1.01.01.2.01.0001", 4, "1.01"},
+		{"1.01.01.2.01.0001", kodeProgramLen, "1.01.01"},
+		{"1.01", kodeProgramLen, tanpaKode},
+		{"", 1, tanpaKode},
+	}
+	for _, tt := range tests {
+		if got := kodePrefix(tt.kode, tt.n); got != tt.want {
+			t.Errorf("kodePrefix(%q, %d) = %q, want %q", tt.kode, tt.n, got, tt.want)
+		}
+	}
+}
+
+func shareByKode(shares []PaguShare) map[string]PaguShare {
+	out := make(map[string]PaguShare, len(shares))
+	for _, s := range shares {
+		out[s.Kode] = s
+	}
+	return out
+}
+
+func TestBreakdownPagu(t *testing.T) {
+	nodes := []PohonKinerjaPemda{{
+		IdPohon: 1, JenisPohon: "Tematik",
+		Childs: []PohonKinerjaPemda{
+			{IdPohon: 2, KodeOpd: "OPD1", RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R1", KodeOpd: "OPD1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 150},
+				// baris kedua R1 tidak dihitung lagi
+				{IdRekin: "R1", KodeOpd: "OPD1", KodeSubkegiatan: "2.01.01.2.01.0001", Pagu: 150},
+				{IdRekin: "R2", KodeOpd: "OPD1", KodeSubkegiatan: "1.02.01.2.01.0001", Pagu: 50},
+			}},
+			// rekin mengikuti OPD pokin pemiliknya, bukan OPD node pemda
+			{IdPohon: 3, KodeOpd: "OPD1", RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R3", KodeOpd: "OPD2", KodeKegiatan: "1.01.02.2.01", Pagu: 200},
+			}},
+			{IdPohon: 4, RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R4", Pagu: 100},
+			}},
+		},
+	}}
+
+	opd, urusan, bidang, program := paguGroup{}, paguGroup{}, paguGroup{}, paguGroup{}
+	total, err := breakdownPagu(nodes, opd, urusan, bidang, program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 500 {
+		t.Fatalf("total = %d, want 500", total)
+	}
+
+	perOpd := opd.shares(total, nil)
+	byOpd := shareByKode(perOpd)
+	for kode, want := range map[string]struct {
+		pagu   Pagu
+		persen float64
+		rekin  int
+	}{
+		"OPD1":    {200, 40, 2},
+		"OPD2":    {200, 40, 1},
+		tanpaKode: {100, 20, 1},
+	} {
+		got := byOpd[kode]
+		if got.Pagu != want.pagu || math.Abs(got.Persen-want.persen) > 1e-9 || got.JumlahRekin != want.rekin {
+			t.Errorf("per_opd %s = %+v, want pagu %d persen %v rekin %d", kode, got, want.pagu, want.persen, want.rekin)
+		}
+	}
+	// pagu terbesar dulu, pagu sama besar diurutkan menurut kode
+	if perOpd[0].Kode != "OPD1" || perOpd[1].Kode != "OPD2" || perOpd[2].Kode != tanpaKode {
+		t.Errorf("urutan per_opd = %v, %v, %v", perOpd[0].Kode, perOpd[1].Kode, perOpd[2].Kode)
+	}
+
+	byUrusan := shareByKode(urusan.shares(total, nil))
+	if byUrusan["1"].Pagu != 400 || byUrusan[tanpaKode].Pagu != 100 || len(byUrusan) != 2 {
+		t.Errorf("per_urusan = %+v", byUrusan)
+	}
+	byProgram := shareByKode(program.shares(total, func(kode string) string { return "Program " + kode }))
+	if byProgram["1.01.01"].Pagu != 150 || byProgram["1.02.01"].Pagu != 50 || byProgram["1.01.02"].Pagu != 200 {
+		t.Errorf("per_program = %+v", byProgram)
+	}
+	if byProgram["1.01.01"].Nama != "Program 1.01.01" || byProgram[tanpaKode].Nama != "" {
+		t.Errorf("nama program = %+v", byProgram)
+	}
+
+	var persen float64
+	for _, s := range perOpd {
+		persen += s.Persen
+	}
+	if math.Abs(persen-100) > 1e-9 {
+		t.Errorf("jumlah persen per_opd = %v, want 100", persen)
+	}
+}
+
+func TestBreakdownPaguEmpty(t *testing.T) {
+	opd := paguGroup{}
+	total, err := breakdownPagu([]PohonKinerjaPemda{{IdPohon: 1}}, opd, paguGroup{}, paguGroup{}, paguGroup{})
+	if err != nil || total != 0 {
+		t.Fatalf("total = %d, %v", total, err)
+	}
+	if shares := opd.shares(total, nil); len(shares) != 0 {
+		t.Errorf("per_opd = %+v, want kosong", shares)
+	}
+}
+
+// Total rincian pagu harus sama dengan pagu Tematik dari buildTematik.
+func TestBreakdownPaguMatchesTematik(t *testing.T) {
+	useFakeDB(t, fakeTree(2025,
+		fakePohon{IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik", Status: statusDisetujui},
+		fakePohon{IdPohon: 2, Parent: 1, NamaPohon: "Strategic", JenisPohon: "Strategic Pemda", Level: 4, Status: statusDisetujui,
+			KodeOpd: "OPD1", Rekins: []RencanaKinerjaAsn{
+				{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100},
+				{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100},
+			}},
+		fakePohon{IdPohon: 3, Parent: 2, NamaPohon: "Operational", JenisPohon: "Operational Pemda", Level: 6, Status: statusDisetujui,
+			KodeOpd: "OPD2", Rekins: []RencanaKinerjaAsn{
+				{IdRekin: "R2", KodeKegiatan: "2.01.01.2.01", KodeSubkegiatan: "2.01.01.2.01.0001", Pagu: 40},
+			}},
+	))
+
+	list, err := buildTematik(context.Background(), 1, 2025, paguOnlyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opd := paguGroup{}
+	total, err := breakdownPagu(list, opd, paguGroup{}, paguGroup{}, paguGroup{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || total != list[0].Pagu || total != 140 {
+		t.Errorf("total rincian %d, pagu tematik %+v, want 140", total, list)
+	}
+	byOpd := shareByKode(opd.shares(total, nil))
+	if byOpd["OPD1"].Pagu != 100 || byOpd["OPD2"].Pagu != 40 {
+		t.Errorf("per_opd = %+v", byOpd)
+	}
+}
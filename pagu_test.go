@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaguAdd(t *testing.T) {
+	tests := []struct {
+		a, b     Pagu
+		want     Pagu
+		overflow bool
+	}{
+		{1000, 234, 1234, false},
+		{-500, 200, -300, false},
+		{math.MaxInt64 - 1, 1, math.MaxInt64, false},
+		{math.MaxInt64, 1, 0, true},
+		{math.MinInt64, -1, 0, true},
+		{math.MinInt64, math.MaxInt64, -1, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.Add(tt.b)
+		if tt.overflow {
+			if !errors.Is(err, ErrPaguOverflow) {
+				t.Errorf("%d.Add(%d) error = %v, want ErrPaguOverflow", tt.a, tt.b, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%d.Add(%d) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestPaguSub(t *testing.T) {
+	if got, err := Pagu(100).Sub(250); err != nil || got != -150 {
+		t.Errorf("100.Sub(250) = %d, %v, want -150", got, err)
+	}
+	if _, err := Pagu(0).Sub(math.MinInt64); !errors.Is(err, ErrPaguOverflow) {
+		t.Errorf("0.Sub(MinInt64) error = %v, want ErrPaguOverflow", err)
+	}
+	if _, err := Pagu(math.MinInt64).Sub(1); !errors.Is(err, ErrPaguOverflow) {
+		t.Errorf("MinInt64.Sub(1) error = %v, want ErrPaguOverflow", err)
+	}
+}
+
+func TestPaguSumKeepsFirstOverflow(t *testing.T) {
+	var s paguSum
+	s.add(math.MaxInt64)
+	s.add(1)
+	s.add(-10)
+	if !errors.Is(s.err, ErrPaguOverflow) {
+		t.Fatalf("paguSum.err = %v, want ErrPaguOverflow", s.err)
+	}
+	if s.total != math.MaxInt64 {
+		t.Errorf("paguSum.total = %d, harus berhenti di nilai sebelum overflow", s.total)
+	}
+}
+
+func TestPaguRupiah(t *testing.T) {
+	tests := []struct {
+		pagu Pagu
+		want string
+	}{
+		{0, "Rp 0"},
+		{999, "Rp 999"},
+		{1000, "Rp 1.000"},
+		{1234567, "Rp 1.234.567"},
+		{-1234567, "-Rp 1.234.567"},
+		{-5, "-Rp 5"},
+		{math.MinInt64, "-Rp 9.223.372.036.854.775.808"},
+	}
+	for _, tt := range tests {
+		if got := tt.pagu.Rupiah(); got != tt.want {
+			t.Errorf("Pagu(%d).Rupiah() = %q, want %q", int64(tt.pagu), got, tt.want)
+		}
+	}
+}
+
+func TestWriteLaporanFormatPagu(t *testing.T) {
+	data := struct {
+		Nama string `json:"nama"`
+		Pagu Pagu   `json:"pagu"`
+		Sub  []struct {
+			Pagu Pagu `json:"pagu"`
+		} `json:"sub"`
+	}{Nama: "a", Pagu: 1500}
+	data.Sub = append(data.Sub, struct {
+		Pagu Pagu `json:"pagu"`
+	}{Pagu: 2000000})
+
+	handler := checkFormatPagu(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeLaporan(w, r, data)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?format_pagu=true", nil))
+
+	var got struct {
+		PaguRupiah string `json:"pagu_rupiah"`
+		Sub        []struct {
+			PaguRupiah string `json:"pagu_rupiah"`
+		} `json:"sub"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.PaguRupiah != "Rp 1.500" || len(got.Sub) != 1 || got.Sub[0].PaguRupiah != "Rp 2.000.000" {
+		t.Errorf("format_pagu=true menghasilkan %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	got.PaguRupiah = ""
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.PaguRupiah != "" {
+		t.Errorf("tanpa format_pagu tidak boleh ada pagu_rupiah: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/?format_pagu=ya", nil))
+	var resp StatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusBadRequest || resp.Status != http.StatusBadRequest {
+		t.Errorf("format_pagu=ya: %d %s, want 400 JSON", w.Code, w.Body.String())
+	}
+}
+
+func TestPaguRekinCountsRekinOnce(t *testing.T) {
+	rekins := []RencanaKinerjaAsn{
+		{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100},
+		{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100},
+		{IdRekin: "R2", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 30},
+	}
+	if got, err := paguRekin(rekins); err != nil || got != 130 {
+		t.Errorf("paguRekin = %d, %v, want 130", got, err)
+	}
+
+	overflow := []RencanaKinerjaAsn{{IdRekin: "R1", Pagu: math.MaxInt64}, {IdRekin: "R2", Pagu: 1}}
+	if _, err := paguRekin(overflow); !errors.Is(err, ErrPaguOverflow) {
+		t.Errorf("paguRekin overflow error = %v, want ErrPaguOverflow", err)
+	}
+}
+
+// fakePohon adalah satu node pemda untuk fakeTree. Rekin dibaca dari pokin
+// OPD hasil clone dengan id IdPohon+1000.
+type fakePohon struct {
+	IdPohon, Parent int
+	NamaPohon       string
+	KodeOpd         string
+	JenisPohon      string
+	Level           int
+	Status          string
+	Rekins          []RencanaKinerjaAsn
+	Tagging         []string
+}
+
+// fakeTree menjawab query pembangun pohon cascading dari daftar node.
+func fakeTree(tahun int, nodes ...fakePohon) fakeQueryFunc {
+	byId := make(map[int64]fakePohon)
+	for _, n := range nodes {
+		byId[int64(n.IdPohon)] = n
+	}
+	row := func(n fakePohon) []driver.Value {
+		return []driver.Value{int64(n.IdPohon), int64(n.Parent), int64(tahun), n.NamaPohon, n.KodeOpd, n.JenisPohon, int64(n.Level), "", n.Status}
+	}
+	return func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.Contains(query, "jenis_pohon = 'Tematik' AND id = ?"):
+			if n, ok := byId[args[1].(int64)]; ok && n.Parent == 0 {
+				r := row(n)
+				return fakeAnswer(append(r[:1:1], r[2:]...))
+			}
+		case strings.Contains(query, "AND parent = ?"):
+			var res [][]driver.Value
+			for _, n := range nodes {
+				if int64(n.Parent) == args[1].(int64) {
+					res = append(res, row(n))
+				}
+			}
+			return fakeAnswer(res...)
+		case strings.Contains(query, "clone_from = ?"):
+			if n, ok := byId[args[1].(int64)]; ok && len(n.Rekins) > 0 {
+				return fakeAnswer([]driver.Value{int64(n.IdPohon + 1000), int64(tahun), n.NamaPohon, n.KodeOpd, n.JenisPohon, "", n.Status})
+			}
+		case strings.Contains(query, "total_anggaran") && strings.Contains(query, "WHERE pokin.id = ?"):
+			var res [][]driver.Value
+			for _, rekin := range byId[args[0].(int64)-1000].Rekins {
+				res = append(res, []driver.Value{rekin.IdRekin, rekin.RencanaKinerja, "Pegawai", "19900101",
+					rekin.KodeKegiatan, "Kegiatan", rekin.KodeSubkegiatan, "Subkegiatan", int64(rekin.Pagu)})
+			}
+			return fakeAnswer(res...)
+		case strings.Contains(query, "FROM tb_tagging_pokin"):
+			var res [][]driver.Value
+			for _, tag := range byId[args[0].(int64)].Tagging {
+				res = append(res, []driver.Value{int64(len(res) + 1), args[0], tag, "", int64(0)})
+			}
+			return fakeAnswer(res...)
+		}
+		return fakeResult{}
+	}
+}
+
+func TestBuildTematikCountsRekinOnce(t *testing.T) {
+	useFakeDB(t, fakeTree(2025,
+		fakePohon{IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik", Status: statusDisetujui},
+		fakePohon{IdPohon: 2, Parent: 1, NamaPohon: "Operational", JenisPohon: "Operational Pemda", Level: 6, Status: statusDisetujui,
+			KodeOpd: "OPD1", Rekins: []RencanaKinerjaAsn{
+				{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100},
+				{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100},
+				{IdRekin: "R2", KodeKegiatan: "1.01.01.2.01", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 30},
+			}},
+	))
+
+	list, err := buildTematik(context.Background(), 1, 2025, paguOnlyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || len(list[0].Childs) != 1 {
+		t.Fatalf("pohon = %+v", list)
+	}
+	node := list[0].Childs[0]
+	if len(node.RencanaKinerjas) != 3 {
+		t.Errorf("rencana_kinerja = %d baris, want 3 (satu per subkegiatan)", len(node.RencanaKinerjas))
+	}
+	if node.Pagu != 130 || list[0].Pagu != 130 {
+		t.Errorf("pagu node %d tematik %d, want 130", node.Pagu, list[0].Pagu)
+	}
+}
+
+// Roll-up yang melewati int64 harus gagal, bukan membungkus ke pagu negatif.
+func TestBuildTematikPaguOverflow(t *testing.T) {
+	useFakeDB(t, fakeTree(2025,
+		fakePohon{IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik", Status: statusDisetujui},
+		fakePohon{IdPohon: 2, Parent: 1, NamaPohon: "Operational A", JenisPohon: "Operational Pemda", Level: 6, Status: statusDisetujui,
+			KodeOpd: "OPD1", Rekins: []RencanaKinerjaAsn{{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: math.MaxInt64}}},
+		fakePohon{IdPohon: 3, Parent: 1, NamaPohon: "Operational B", JenisPohon: "Operational Pemda", Level: 6, Status: statusDisetujui,
+			KodeOpd: "OPD2", Rekins: []RencanaKinerjaAsn{{IdRekin: "R2", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 1}}},
+	))
+
+	list, err := buildTematik(context.Background(), 1, 2025, paguOnlyOptions())
+	if !errors.Is(err, ErrPaguOverflow) {
+		t.Fatalf("err = %v, want ErrPaguOverflow (pohon %+v)", err, list)
+	}
+}
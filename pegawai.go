@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type PegawaiView struct {
+	NIP      string        `json:"pegawai_id"`
+	Nama     string        `json:"nama_pegawai"`
+	Tahun    int           `json:"tahun"`
+	Pagu     Pagu          `json:"pagu"`
+	Tematiks []TematikPagu `json:"tematiks"`
+	Nodes    []RekinNode   `json:"nodes"`
+	// rekin di pokin OPD yang belum terhubung ke pohon Tematik pemda
+	TanpaTematik []RencanaKinerjaAsn `json:"rencana_kinerja_tanpa_tematik,omitempty"`
+}
+
+type PegawaiResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    PegawaiView `json:"data"`
+}
+
+// loadRekinIndikator melengkapi rekin dengan indikator kegiatan dan
+// subkegiatan beserta targetnya, sama seperti di cascading.
+func loadRekinIndikator(ctx context.Context, rekins []RencanaKinerjaAsn, tahun int) error {
+	for i := range rekins {
+		rekin := &rekins[i]
+		if rekin.KodeKegiatan != "" {
+			indKegs, err := getIndikatorsPKS(ctx, rekin.KodeKegiatan, tahun, true)
+			if err != nil {
+				return fmt.Errorf("scan ind keg error: %w", err)
+			}
+			rekin.IndikatorKegiatan = indKegs
+		}
+		if rekin.KodeSubkegiatan != "" {
+			indSubKegs, err := getIndikatorsPKS(ctx, rekin.KodeSubkegiatan, tahun, true)
+			if err != nil {
+				return fmt.Errorf("scan ind sub error: %w", err)
+			}
+			rekin.IndikatorSubkegiatan = indSubKegs
+		}
+	}
+	return nil
+}
+
+func pegawaiHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	nip := strings.TrimSpace(r.URL.Query().Get("pegawai_id"))
+	if nip == "" {
+		nip = strings.TrimSpace(r.URL.Query().Get("nip"))
+	}
+	if nip == "" {
+		writeError(w, http.StatusBadRequest, "params pegawai_id is required, misal: ?pegawai_id=198001012005011001&tahun=2025")
+		return
+	}
+	tahun, err := paramInt(r.URL.Query(), "tahun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?pegawai_id=198001012005011001&tahun=2025")
+		return
+	}
+
+	var nama string
+	err = withRetry(ctx, cfg.DB, func() error {
+		return db.QueryRowContext(ctx, `SELECT nama FROM tb_pegawai WHERE nip = ? LIMIT 1`, nip).Scan(&nama)
+	})
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("pegawai %s tidak ditemukan", nip))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("query error: %v", err))
+		return
+	}
+
+	rekins, err := queryRekinNodes(ctx, tahun, "rekin.pegawai_id = ?", nip)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	idx, err := loadPohonIndex(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	nodes, tematiks, total, unlinked, err := groupRekinNodes(idx, rekins, restrictedOpd(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for i := range nodes {
+		inds, err := getIndikators(ctx, nodes[i].IdPohon, tahun, true)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		nodes[i].Indikators = inds
+		if err := loadRekinIndikator(ctx, nodes[i].RencanaKinerjas, tahun); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := loadRekinIndikator(ctx, unlinked, tahun); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// rekin tanpa Tematik tetap masuk total pegawai
+	unlinkedPagu, err := paguRekin(unlinked)
+	if err == nil {
+		total, err = total.Add(unlinkedPagu)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeLaporan(w, r, PegawaiResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Kontribusi %s Tahun %d", nama, tahun),
+		Data: PegawaiView{
+			NIP:          nip,
+			Nama:         nama,
+			Tahun:        tahun,
+			Pagu:         total,
+			Tematiks:     tematiks,
+			Nodes:        nodes,
+			TanpaTematik: unlinked,
+		},
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PathNode adalah satu langkah di jalur dari Tematik ke sebuah node.
+type PathNode struct {
+	IdPohon    int        `json:"id_pohon"`
+	NamaPohon  string     `json:"nama_pohon"`
+	JenisPohon JenisPohon `json:"jenis_pohon"`
+}
+
+type pohonEntry struct {
+	IdPohon    int
+	Parent     int
+	NamaPohon  string
+	KodeOpd    string
+	JenisPohon JenisPohon
+	LevelPohon int
+	Status     string
+	CloneFrom  int
+}
+
+// pohonIndex memuat seluruh pohon kinerja satu tahun dalam satu query
+// supaya jalur ke Tematik bisa dicari tanpa query per node.
+type pohonIndex struct {
+	tahun int
+	nodes map[int]pohonEntry
+	// byCloneFrom memetakan node pemda ke pokin OPD hasil clone di tahun yang sama,
+	// jalur yang dipakai findPokinById untuk mengambil rencana kinerja
+	byCloneFrom map[int][]int
+}
+
+func loadPohonIndex(ctx context.Context, tahun int) (*pohonIndex, error) {
+	rows, err := queryRetry(ctx, `SELECT id, parent, nama_pohon, kode_opd, jenis_pohon, level_pohon, status, clone_from
+		FROM tb_pohon_kinerja
+		WHERE tahun = ?`, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query pohon index error: %w", err)
+	}
+	defer rows.Close()
+
+	idx := &pohonIndex{
+		tahun:       tahun,
+		nodes:       make(map[int]pohonEntry),
+		byCloneFrom: make(map[int][]int),
+	}
+	for rows.Next() {
+		var e pohonEntry
+		var kodeOpd, status sql.NullString
+		var cloneFrom sql.NullInt64
+		if err := rows.Scan(&e.IdPohon, &e.Parent, &e.NamaPohon, &kodeOpd, &e.JenisPohon,
+			&e.LevelPohon, &status, &cloneFrom); err != nil {
+			return nil, fmt.Errorf("scan pohon index error: %w", err)
+		}
+		e.KodeOpd = kodeOpd.String
+		e.Status = status.String
+		e.CloneFrom = int(cloneFrom.Int64)
+
+		idx.nodes[e.IdPohon] = e
+		if e.CloneFrom != 0 {
+			idx.byCloneFrom[e.CloneFrom] = append(idx.byCloneFrom[e.CloneFrom], e.IdPohon)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return idx, nil
+}
+
+// path mengembalikan jalur dari akar ke node id (termasuk node itu sendiri).
+func (idx *pohonIndex) path(id int) []PathNode {
+	var rev []PathNode
+	seen := make(map[int]bool)
+	for cur, ok := idx.nodes[id]; ok && !seen[cur.IdPohon]; cur, ok = idx.nodes[cur.Parent] {
+		seen[cur.IdPohon] = true
+		rev = append(rev, PathNode{IdPohon: cur.IdPohon, NamaPohon: cur.NamaPohon, JenisPohon: cur.JenisPohon})
+		if cur.Parent == 0 {
+			break
+		}
+	}
+
+	path := make([]PathNode, len(rev))
+	for i, p := range rev {
+		path[len(rev)-1-i] = p
+	}
+	return path
+}
+
+// tematikOf mengembalikan akar Tematik dari node, ok=false bila node
+// tidak berada di bawah pohon Tematik pemda.
+func (idx *pohonIndex) tematikOf(id int) (PathNode, bool) {
+	path := idx.path(id)
+	if len(path) == 0 || path[0].JenisPohon != "Tematik" {
+		return PathNode{}, false
+	}
+	return path[0], true
+}
+
+// pemdaNodeOf memetakan pokin OPD (pemilik rencana kinerja) ke node pemda
+// asal clone-nya. Pokin yang bukan hasil clone dikembalikan apa adanya.
+func (idx *pohonIndex) pemdaNodeOf(id int) int {
+	if e, ok := idx.nodes[id]; ok && e.CloneFrom != 0 {
+		if _, ok := idx.nodes[e.CloneFrom]; ok {
+			return e.CloneFrom
+		}
+	}
+	return id
+}
+
+// visibleTo menandai node yang boleh dilihat role OPD: node atau salah
+// satu leluhurnya milik kodeOpd. kodeOpd kosong berarti semua boleh.
+func (idx *pohonIndex) visibleTo(id int, kodeOpd string) bool {
+	if kodeOpd == "" {
+		return true
+	}
+	seen := make(map[int]bool)
+	for cur, ok := idx.nodes[id]; ok && !seen[cur.IdPohon]; cur, ok = idx.nodes[cur.Parent] {
+		seen[cur.IdPohon] = true
+		if cur.KodeOpd == kodeOpd {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func (rl RateLimit) validate(name string) error {
+	if rl.Rate <= 0 || rl.Burst < 1 {
+		return fmt.Errorf("limit %s: rate harus > 0 dan burst >= 1", name)
+	}
+	return nil
+}
+
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// take mengambil satu token. Bila habis, kembalikan lama tunggu sampai token berikutnya.
+func (b *tokenBucket) take(now time.Time, limit RateLimit) (bool, time.Duration) {
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+type rateLimiter struct {
+	limitCfg LimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limitCfg LimitConfig) *rateLimiter {
+	return &rateLimiter{limitCfg: limitCfg, buckets: make(map[string]*tokenBucket)}
+}
+
+func (rl *rateLimiter) limitFor(endpoint string) RateLimit {
+	if l, ok := rl.limitCfg.Endpoints[endpoint]; ok {
+		return l
+	}
+	return rl.limitCfg.Default
+}
+
+func (rl *rateLimiter) allow(endpoint, client string) (bool, time.Duration) {
+	limit := rl.limitFor(endpoint)
+	now := time.Now()
+	key := endpoint + "|" + client
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		rl.buckets[key] = b
+	}
+	return b.take(now, limit)
+}
+
+// sweep membuang bucket client yang sudah lama tidak aktif.
+func (rl *rateLimiter) sweep(ctx context.Context) {
+	ttl := time.Duration(rl.limitCfg.ClientIdleTTL)
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			rl.mu.Lock()
+			for key, b := range rl.buckets {
+				if now.Sub(b.lastSeen) > ttl {
+					delete(rl.buckets, key)
+				}
+			}
+			rl.mu.Unlock()
+		}
+	}
+}
+
+// clientIdentity memakai identitas hasil autentikasi, atau IP untuk client anonim.
+func (rl *rateLimiter) clientIdentity(r *http.Request) string {
+	if p, ok := principalFrom(r.Context()); ok {
+		switch {
+		case p.APIKey != "":
+			return "apikey:" + p.APIKey
+		case p.Subject != "":
+			return "sub:" + p.Subject
+		}
+	}
+
+	if rl.limitCfg.TrustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			first, _, _ := strings.Cut(fwd, ",")
+			return "ip:" + strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
+
+// middleware membatasi request per client untuk satu endpoint.
+func (rl *rateLimiter) middleware(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := rl.clientIdentity(r)
+		if ok, wait := rl.allow(endpoint, client); !ok {
+			log.Printf("[WARN] rate limit %s untuk %s", endpoint, client)
+			w.Header().Set("Retry-After", retryAfterSeconds(wait))
+			writeError(w, http.StatusTooManyRequests, "terlalu banyak request, coba lagi nanti")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// buildLimiter membatasi jumlah pohon cascading yang dibangun bersamaan
+// supaya pool koneksi database tidak habis. nil berarti tanpa batas.
+type buildLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+var builds *buildLimiter
+
+func newBuildLimiter(limitCfg LimitConfig) *buildLimiter {
+	if limitCfg.MaxConcurrentBuilds <= 0 {
+		return nil
+	}
+	return &buildLimiter{
+		slots:   make(chan struct{}, limitCfg.MaxConcurrentBuilds),
+		timeout: time.Duration(limitCfg.BuildQueueTimeout),
+	}
+}
+
+// acquire menunggu slot build paling lama bl.timeout.
+func (bl *buildLimiter) acquire(ctx context.Context) bool {
+	if bl == nil {
+		return true
+	}
+
+	timer := time.NewTimer(bl.timeout)
+	defer timer.Stop()
+
+	select {
+	case bl.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (bl *buildLimiter) release() {
+	if bl == nil {
+		return
+	}
+	<-bl.slots
+}
+
+func (bl *buildLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bl.acquire(r.Context()) {
+			w.Header().Set("Retry-After", retryAfterSeconds(bl.timeout))
+			writeError(w, http.StatusTooManyRequests, "server sedang sibuk membangun laporan, coba lagi nanti")
+			return
+		}
+		defer bl.release()
+
+		next.ServeHTTP(w, r)
+	})
+}
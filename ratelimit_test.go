@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	limit := RateLimit{Rate: 2, Burst: 3}
+	now := time.Now()
+	b := &tokenBucket{tokens: float64(limit.Burst), last: now}
+
+	for i := range limit.Burst {
+		if ok, _ := b.take(now, limit); !ok {
+			t.Fatalf("take ke-%d ditolak, burst %d", i+1, limit.Burst)
+		}
+	}
+	ok, wait := b.take(now, limit)
+	if ok {
+		t.Fatal("take setelah burst habis harus ditolak")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want 500ms untuk rate 2/detik", wait)
+	}
+
+	// setengah detik kemudian satu token terisi lagi
+	if ok, _ := b.take(now.Add(500*time.Millisecond), limit); !ok {
+		t.Error("token harus terisi setelah 500ms")
+	}
+	// lama tidak aktif tidak melebihi burst
+	now = now.Add(time.Hour)
+	for i := range limit.Burst {
+		if ok, _ := b.take(now, limit); !ok {
+			t.Fatalf("take ke-%d setelah idle ditolak", i+1)
+		}
+	}
+	if ok, _ := b.take(now, limit); ok {
+		t.Error("token setelah idle tidak boleh melebihi burst")
+	}
+}
+
+func TestRateLimiterPerEndpoint(t *testing.T) {
+	rl := newRateLimiter(LimitConfig{
+		Default:   RateLimit{Rate: 1, Burst: 1},
+		Endpoints: map[string]RateLimit{"/batch": {Rate: 1, Burst: 2}},
+	})
+	if ok, _ := rl.allow("/a", "ip:1"); !ok {
+		t.Fatal("request pertama harus lolos")
+	}
+	if ok, _ := rl.allow("/a", "ip:1"); ok {
+		t.Error("burst default 1 harus menolak request kedua")
+	}
+	if ok, _ := rl.allow("/a", "ip:2"); !ok {
+		t.Error("client lain punya bucket sendiri")
+	}
+	for i := range 2 {
+		if ok, _ := rl.allow("/batch", "ip:1"); !ok {
+			t.Errorf("request batch ke-%d harus lolos dengan burst 2", i+1)
+		}
+	}
+}
+
+func TestRateLimiterClientIdentity(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	if got := newRateLimiter(LimitConfig{}).clientIdentity(r); got != "ip:10.0.0.1" {
+		t.Errorf("tanpa trust_proxy identity = %s, want ip:10.0.0.1", got)
+	}
+	if got := newRateLimiter(LimitConfig{TrustProxy: true}).clientIdentity(r); got != "ip:203.0.113.7" {
+		t.Errorf("dengan trust_proxy identity = %s, want ip:203.0.113.7", got)
+	}
+
+	ctx := withPrincipal(context.Background(), APIKey{Id: "k"}.principal())
+	if got := newRateLimiter(LimitConfig{}).clientIdentity(r.WithContext(ctx)); got != "apikey:k" {
+		t.Errorf("client api key identity = %s, want apikey:k", got)
+	}
+}
+
+func TestDefaultLimitOff(t *testing.T) {
+	limitCfg := defaultConfig().Limit
+	if limitCfg.Enabled {
+		t.Error("rate limit harus mati secara bawaan")
+	}
+
+	// slot build tidak pernah habis dengan konfigurasi bawaan
+	bl := newBuildLimiter(limitCfg)
+	if bl != nil {
+		t.Fatalf("batas build harus mati secara bawaan, dapat %d slot", cap(bl.slots))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := range 100 {
+		if !bl.acquire(ctx) {
+			t.Fatalf("acquire ke-%d ditolak dengan konfigurasi bawaan", i+1)
+		}
+	}
+	bl.release()
+}
+
+func TestBuildLimiterQueueTimeout(t *testing.T) {
+	bl := newBuildLimiter(LimitConfig{MaxConcurrentBuilds: 1, BuildQueueTimeout: Duration(10 * time.Millisecond)})
+	if !bl.acquire(context.Background()) {
+		t.Fatal("slot pertama harus didapat")
+	}
+	if bl.acquire(context.Background()) {
+		t.Error("slot kedua harus habis waktu antre")
+	}
+	bl.release()
+	if !bl.acquire(context.Background()) {
+		t.Error("slot harus bisa dipakai lagi setelah release")
+	}
+}
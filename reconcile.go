@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// alasan rekin tidak ikut terhitung di pagu pohon
+const (
+	alasanTanpaRenaksi     = "tanpa_rencana_aksi"
+	alasanTanpaRinbel      = "tanpa_rincian_belanja"
+	alasanTanpaSubkegiatan = "tanpa_subkegiatan"
+	alasanDiLuarPohon      = "di_luar_pohon"
+	alasanBelumDisetujui   = "pohon_belum_disetujui"
+)
+
+type RekinReconcile struct {
+	IdRekin         string     `json:"id_rencana_kinerja"`
+	RencanaKinerja  string     `json:"nama_rencana_kinerja"`
+	NIPPelaksana    string     `json:"pegawai_id"`
+	KodeOpd         string     `json:"kode_opd"`
+	KodeSubkegiatan []string   `json:"kode_subkegiatan"`
+	Anggaran        Pagu       `json:"anggaran"`
+	PaguPohon       Pagu       `json:"pagu_pohon"`
+	Dihitung        int        `json:"dihitung"`
+	Nodes           []PathNode `json:"nodes,omitempty"`
+	Alasan          string     `json:"alasan,omitempty"`
+}
+
+// ReconcileRow adalah satu pasangan (rekin, subkegiatan). Rincian belanja
+// menempel di rekin, bukan di subkegiatan, jadi rekin dengan beberapa
+// subkegiatan memakai RincianBelanja yang sama di setiap barisnya dan
+// SubkegiatanBersama > 1. Baris seperti itu tidak boleh dijumlah,
+// totalnya ada di ReconcileReport.
+type ReconcileRow struct {
+	KodeOpd            string `json:"kode_opd"`
+	IdRekin            string `json:"id_rencana_kinerja"`
+	KodeSubkegiatan    string `json:"kode_subkegiatan"`
+	PaguPohon          Pagu   `json:"pagu_pohon"`
+	RincianBelanja     Pagu   `json:"rincian_belanja"`
+	SubkegiatanBersama int    `json:"subkegiatan_bersama"`
+	Selisih            Pagu   `json:"selisih"`
+}
+
+// ReconcileOpd adalah total per OPD di samping Rows. Setiap rekin dihitung
+// sekali, jadi totalnya bisa dibandingkan dengan total laporan.
+type ReconcileOpd struct {
+	KodeOpd        string `json:"kode_opd"`
+	PaguPohon      Pagu   `json:"pagu_pohon"`
+	RincianBelanja Pagu   `json:"rincian_belanja"`
+	Selisih        Pagu   `json:"selisih"`
+	JumlahRekin    int    `json:"jumlah_rekin"`
+}
+
+type ReconcileReport struct {
+	Tahun     int  `json:"tahun"`
+	TematikId int  `json:"tematik_id,omitempty"`
+	PaguPohon Pagu `json:"pagu_pohon"`
+	// RincianBelanja adalah jumlah langsung anggaran rincian belanja semua
+	// rekin dalam cakupan, tanpa melewati pohon. Setiap rekin dihitung
+	// sekali walaupun muncul di beberapa baris Rows.
+	RincianBelanja Pagu           `json:"rincian_belanja"`
+	Selisih        Pagu           `json:"selisih"`
+	PerOpd         []ReconcileOpd `json:"per_opd"`
+	Rows           []ReconcileRow `json:"rows"`
+	// DoubleCounted berisi rekin yang ikut dijumlah di lebih dari satu node.
+	// Beberapa baris subkegiatan di node yang sama bukan hitung ganda karena
+	// pagu node memakai paguRekin.
+	DoubleCounted []RekinReconcile `json:"dihitung_ganda"`
+	Excluded      []RekinReconcile `json:"tidak_terhitung"`
+	Errors        []BatchResult    `json:"errors,omitempty"`
+}
+
+type ReconcileResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    ReconcileReport `json:"data"`
+}
+
+type rekinDirect struct {
+	RekinReconcile
+	idPohon int
+	renaksi int
+	rinbel  int
+}
+
+// getRekinDirect menjumlahkan rincian belanja per rekin langsung dari
+// tabelnya, dengan LEFT JOIN supaya rekin tanpa renaksi tetap terbaca.
+func getRekinDirect(ctx context.Context, tahun int) (map[string]*rekinDirect, error) {
+	rows, err := queryRetry(ctx, `
+		SELECT rekin.id,
+		       rekin.nama_rencana_kinerja,
+		       rekin.pegawai_id,
+		       rekin.id_pohon,
+		       pokin.kode_opd,
+		       COUNT(DISTINCT renaksi.id),
+		       COUNT(rinbel.id),
+		       SUM(rinbel.anggaran)
+		FROM tb_rencana_kinerja rekin
+		JOIN tb_pohon_kinerja pokin ON pokin.id = rekin.id_pohon
+		LEFT JOIN tb_rencana_aksi renaksi ON renaksi.rencana_kinerja_id = rekin.id
+		LEFT JOIN tb_rincian_belanja rinbel ON rinbel.renaksi_id = renaksi.id
+		WHERE pokin.tahun = ?
+		GROUP BY rekin.id, rekin.nama_rencana_kinerja, rekin.pegawai_id, rekin.id_pohon, pokin.kode_opd`, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query rincian belanja error: %w", err)
+	}
+	defer rows.Close()
+
+	direct := make(map[string]*rekinDirect)
+	for rows.Next() {
+		var d rekinDirect
+		var nip, kodeOpd sql.NullString
+		var anggaran sql.NullInt64
+		if err := rows.Scan(&d.IdRekin, &d.RencanaKinerja, &nip, &d.idPohon, &kodeOpd,
+			&d.renaksi, &d.rinbel, &anggaran); err != nil {
+			return nil, fmt.Errorf("scan rincian belanja error: %w", err)
+		}
+		d.NIPPelaksana = nip.String
+		d.KodeOpd = kodeOpd.String
+		d.Anggaran = Pagu(anggaran.Int64)
+		direct[d.IdRekin] = &d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// subkegiatan rekin, bisa lebih dari satu
+	subRows, err := queryRetry(ctx, `
+		SELECT sub_rekin.rekin_id, sub_rekin.kode_subkegiatan
+		FROM tb_subkegiatan_terpilih sub_rekin
+		JOIN tb_rencana_kinerja rekin ON rekin.id = sub_rekin.rekin_id
+		JOIN tb_pohon_kinerja pokin ON pokin.id = rekin.id_pohon
+		WHERE pokin.tahun = ?
+		ORDER BY sub_rekin.kode_subkegiatan`, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query subkegiatan rekin error: %w", err)
+	}
+	defer subRows.Close()
+	for subRows.Next() {
+		var rekinId string
+		var kode sql.NullString
+		if err := subRows.Scan(&rekinId, &kode); err != nil {
+			return nil, fmt.Errorf("scan subkegiatan rekin error: %w", err)
+		}
+		if d, ok := direct[rekinId]; ok && kode.String != "" {
+			d.KodeSubkegiatan = append(d.KodeSubkegiatan, kode.String)
+		}
+	}
+	if err := subRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return direct, nil
+}
+
+type rekinOccurrence struct {
+	node            PathNode
+	kodeSubkegiatan string
+	pagu            Pagu
+}
+
+// collectOccurrences mencatat setiap baris rekin di pohon beserta node-nya.
+func collectOccurrences(nodes []PohonKinerjaPemda, occ map[string][]rekinOccurrence) {
+	for _, n := range nodes {
+		for _, rekin := range n.RencanaKinerjas {
+			occ[rekin.IdRekin] = append(occ[rekin.IdRekin], rekinOccurrence{
+				node:            PathNode{IdPohon: n.IdPohon, NamaPohon: n.NamaPohon, JenisPohon: n.JenisPohon},
+				kodeSubkegiatan: rekin.KodeSubkegiatan,
+				pagu:            rekin.Pagu,
+			})
+		}
+		collectOccurrences(n.Childs, occ)
+	}
+}
+
+// excludedReason menjelaskan kenapa rekin tanpa kemunculan di pohon
+// tidak ikut terhitung, mengikuti join di getRencanaKinerjaPokin.
+func excludedReason(d *rekinDirect, idx *pohonIndex) string {
+	node := idx.pemdaNodeOf(d.idPohon)
+	switch {
+	case d.renaksi == 0:
+		return alasanTanpaRenaksi
+	case d.rinbel == 0:
+		return alasanTanpaRinbel
+	case len(d.KodeSubkegiatan) == 0:
+		return alasanTanpaSubkegiatan
+	case node == d.idPohon:
+		return alasanDiLuarPohon
+	case idx.nodes[node].Status != statusDisetujui:
+		return alasanBelumDisetujui
+	}
+	return alasanDiLuarPohon
+}
+
+// reconcile membandingkan pagu pohon dengan rincian belanja per rekin.
+// kodeOpd tidak kosong membatasi laporan ke rekin OPD tersebut.
+func reconcile(trees [][]PohonKinerjaPemda, direct map[string]*rekinDirect, idx *pohonIndex, tematikId int, kodeOpd string) (ReconcileReport, error) {
+	occ := make(map[string][]rekinOccurrence)
+	for _, list := range trees {
+		collectOccurrences(list, occ)
+	}
+
+	report := ReconcileReport{}
+	var rows []*ReconcileRow
+	perOpd := make(map[string]*ReconcileOpd)
+
+	for id, d := range direct {
+		if kodeOpd != "" && !strings.EqualFold(d.KodeOpd, kodeOpd) {
+			continue
+		}
+		// cakupan satu tematik: hanya rekin yang node pemda-nya di bawah tematik itu
+		if tematikId != 0 {
+			tematik, ok := idx.tematikOf(idx.pemdaNodeOf(d.idPohon))
+			if !ok || tematik.IdPohon != tematikId {
+				continue
+			}
+		}
+
+		// satu baris per subkegiatan, rincian belanja rekin dipakai bersama
+		bySub := make(map[string]*ReconcileRow)
+		row := func(kodeSub string) *ReconcileRow {
+			if bySub[kodeSub] == nil {
+				bySub[kodeSub] = &ReconcileRow{
+					KodeOpd:            d.KodeOpd,
+					IdRekin:            id,
+					KodeSubkegiatan:    kodeSub,
+					RincianBelanja:     d.Anggaran,
+					SubkegiatanBersama: max(len(d.KodeSubkegiatan), 1),
+				}
+				rows = append(rows, bySub[kodeSub])
+			}
+			return bySub[kodeSub]
+		}
+		if len(d.KodeSubkegiatan) == 0 {
+			row("")
+		}
+		for _, kode := range d.KodeSubkegiatan {
+			row(kode)
+		}
+
+		// pagu pohon mengikuti paguRekin: sekali per node walaupun rekin
+		// muncul di beberapa baris subkegiatan
+		var paguPohon paguSum
+		var err error
+		counted := make(map[int]bool)
+		for _, o := range occ[id] {
+			if r := row(o.kodeSubkegiatan); err == nil {
+				r.PaguPohon, err = r.PaguPohon.Add(o.pagu)
+			}
+			if counted[o.node.IdPohon] {
+				continue
+			}
+			counted[o.node.IdPohon] = true
+			paguPohon.add(o.pagu)
+			d.Nodes = append(d.Nodes, o.node)
+		}
+		d.PaguPohon = paguPohon.total
+		d.Dihitung = len(d.Nodes)
+
+		if err == nil {
+			err = paguPohon.err
+		}
+		if err == nil {
+			report.RincianBelanja, err = report.RincianBelanja.Add(d.Anggaran)
+		}
+		if err == nil {
+			report.PaguPohon, err = report.PaguPohon.Add(d.PaguPohon)
+		}
+		opd := perOpd[d.KodeOpd]
+		if opd == nil {
+			opd = &ReconcileOpd{KodeOpd: d.KodeOpd}
+			perOpd[d.KodeOpd] = opd
+		}
+		opd.JumlahRekin++
+		if err == nil {
+			opd.RincianBelanja, err = opd.RincianBelanja.Add(d.Anggaran)
+		}
+		if err == nil {
+			opd.PaguPohon, err = opd.PaguPohon.Add(d.PaguPohon)
+		}
+		if err != nil {
+			return report, fmt.Errorf("pagu rekin %s: %w", id, err)
+		}
+
+		switch {
+		case d.Dihitung > 1:
+			report.DoubleCounted = append(report.DoubleCounted, d.RekinReconcile)
+		case d.Dihitung == 0:
+			d.Alasan = excludedReason(d, idx)
+			report.Excluded = append(report.Excluded, d.RekinReconcile)
+		}
+	}
+
+	var err error
+	for _, r := range rows {
+		if r.Selisih, err = r.PaguPohon.Sub(r.RincianBelanja); err != nil {
+			return report, fmt.Errorf("selisih pagu rekin %s %s: %w", r.IdRekin, r.KodeSubkegiatan, err)
+		}
+		if r.Selisih != 0 {
+			report.Rows = append(report.Rows, *r)
+		}
+	}
+	if report.Selisih, err = report.PaguPohon.Sub(report.RincianBelanja); err != nil {
+		return report, fmt.Errorf("selisih pagu: %w", err)
+	}
+	report.PerOpd = make([]ReconcileOpd, 0, len(perOpd))
+	for _, opd := range perOpd {
+		if opd.Selisih, err = opd.PaguPohon.Sub(opd.RincianBelanja); err != nil {
+			return report, fmt.Errorf("selisih pagu OPD %s: %w", opd.KodeOpd, err)
+		}
+		report.PerOpd = append(report.PerOpd, *opd)
+	}
+	sort.Slice(report.PerOpd, func(i, j int) bool { return report.PerOpd[i].KodeOpd < report.PerOpd[j].KodeOpd })
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.KodeOpd != b.KodeOpd {
+			return a.KodeOpd < b.KodeOpd
+		}
+		if a.IdRekin != b.IdRekin {
+			return a.IdRekin < b.IdRekin
+		}
+		return a.KodeSubkegiatan < b.KodeSubkegiatan
+	})
+	byRekin := func(list []RekinReconcile) {
+		sort.Slice(list, func(i, j int) bool { return list[i].IdRekin < list[j].IdRekin })
+	}
+	byRekin(report.DoubleCounted)
+	byRekin(report.Excluded)
+	return report, nil
+}
+
+func reconcileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tahun, err := paramInt(r.URL.Query(), "tahun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?tahun=2025&tematikId=1")
+		return
+	}
+	// tematikId kosong berarti semua tematik di tahun itu
+	var tematikId int
+	if v := r.URL.Query().Get("tematikId"); v != "" {
+		tematikId, err = strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid tematikId")
+			return
+		}
+	}
+
+	idx, err := loadPohonIndex(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var tematikIds []int
+	if tematikId != 0 {
+		tematikIds = []int{tematikId}
+	} else {
+		for id, e := range idx.nodes {
+			if e.Parent == 0 && e.LevelPohon == 0 && e.JenisPohon == "Tematik" {
+				tematikIds = append(tematikIds, id)
+			}
+		}
+		sort.Ints(tematikIds)
+	}
+
+	direct, err := getRekinDirect(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// pagu pohon dihitung persis seperti cascading, tanpa indikator
+	opts := paguOnlyOptions()
+
+	trees := make([][]PohonKinerjaPemda, len(tematikIds))
+	var mu sync.Mutex
+	var failed []BatchResult
+	forEachTematik(tematikIds, func(i, id int) {
+		if !builds.acquire(r.Context()) {
+			mu.Lock()
+			failed = append(failed, BatchResult{TematikId: id, Status: http.StatusTooManyRequests,
+				Error: "server sedang sibuk membangun laporan, coba lagi nanti"})
+			mu.Unlock()
+			return
+		}
+		list, err := buildTematik(ctx, id, tahun, opts)
+		builds.release()
+		if err != nil {
+			mu.Lock()
+			failed = append(failed, BatchResult{TematikId: id, Status: http.StatusInternalServerError, Error: err.Error()})
+			mu.Unlock()
+			return
+		}
+		trees[i] = list
+	})
+
+	report, err := reconcile(trees, direct, idx, tematikId, restrictedOpd(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	report.Tahun = tahun
+	report.TematikId = tematikId
+	report.Errors = failed
+
+	writeLaporan(w, r, ReconcileResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Rekonsiliasi Pagu Tahun %d", tahun),
+		Data:    report,
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconcileRowPerSubkegiatan(t *testing.T) {
+	idx := &pohonIndex{nodes: map[int]pohonEntry{
+		1: {IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik"},
+		2: {IdPohon: 2, Parent: 1, NamaPohon: "Operational", JenisPohon: "Operational Pemda", Status: statusDisetujui},
+	}}
+	trees := [][]PohonKinerjaPemda{{{
+		IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik",
+		Childs: []PohonKinerjaPemda{{
+			IdPohon: 2, NamaPohon: "Operational", JenisPohon: "Operational Pemda",
+			RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100},
+				{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100},
+			},
+		}},
+	}}}
+	direct := map[string]*rekinDirect{
+		"R1": {RekinReconcile: RekinReconcile{IdRekin: "R1", KodeOpd: "OPD1",
+			KodeSubkegiatan: []string{"1.01.01.2.01.0001", "1.01.01.2.01.0002"}, Anggaran: 100},
+			idPohon: 2, renaksi: 1, rinbel: 1},
+		"R2": {RekinReconcile: RekinReconcile{IdRekin: "R2", KodeOpd: "OPD1",
+			KodeSubkegiatan: []string{"1.01.01.2.01.0003"}, Anggaran: 50},
+			idPohon: 2, renaksi: 0},
+	}
+
+	report, err := reconcile(trees, direct, idx, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// R1 dihitung sekali di pohon walaupun punya dua baris subkegiatan
+	if report.RincianBelanja != 150 || report.PaguPohon != 100 || report.Selisih != -50 {
+		t.Errorf("total = pohon %d, rinbel %d, selisih %d, want 100, 150, -50",
+			report.PaguPohon, report.RincianBelanja, report.Selisih)
+	}
+	if len(report.DoubleCounted) != 0 {
+		t.Errorf("dihitung_ganda = %+v, want kosong", report.DoubleCounted)
+	}
+	// total OPD sama dengan total laporan, bukan jumlah baris
+	want := []ReconcileOpd{{KodeOpd: "OPD1", PaguPohon: 100, RincianBelanja: 150, Selisih: -50, JumlahRekin: 2}}
+	if !reflect.DeepEqual(report.PerOpd, want) {
+		t.Errorf("per_opd = %+v, want %+v", report.PerOpd, want)
+	}
+	if len(report.Excluded) != 1 || report.Excluded[0].Alasan != alasanTanpaRenaksi {
+		t.Errorf("tidak_terhitung = %+v, want R2 tanpa renaksi", report.Excluded)
+	}
+	// baris R1 per subkegiatan selisihnya nol, hanya baris R2 yang tersisa
+	if len(report.Rows) != 1 {
+		t.Fatalf("rows = %+v, want satu baris R2", report.Rows)
+	}
+	r := report.Rows[0]
+	if r.IdRekin != "R2" || r.KodeSubkegiatan != "1.01.01.2.01.0003" || r.Selisih != -50 || r.SubkegiatanBersama != 1 {
+		t.Errorf("row = %+v", r)
+	}
+}
+
+func TestReconcileDoubleCountedAcrossNodes(t *testing.T) {
+	idx := &pohonIndex{nodes: map[int]pohonEntry{
+		1: {IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik"},
+		2: {IdPohon: 2, Parent: 1, NamaPohon: "Operational A", JenisPohon: "Operational Pemda", Status: statusDisetujui},
+		3: {IdPohon: 3, Parent: 1, NamaPohon: "Operational B", JenisPohon: "Operational Pemda", Status: statusDisetujui},
+	}}
+	rekin := RencanaKinerjaAsn{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100}
+	trees := [][]PohonKinerjaPemda{{{
+		IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik",
+		Childs: []PohonKinerjaPemda{
+			{IdPohon: 2, NamaPohon: "Operational A", RencanaKinerjas: []RencanaKinerjaAsn{rekin}},
+			{IdPohon: 3, NamaPohon: "Operational B", RencanaKinerjas: []RencanaKinerjaAsn{rekin}},
+		},
+	}}}
+	direct := map[string]*rekinDirect{
+		"R1": {RekinReconcile: RekinReconcile{IdRekin: "R1", KodeOpd: "OPD1",
+			KodeSubkegiatan: []string{"1.01.01.2.01.0001"}, Anggaran: 100},
+			idPohon: 2, renaksi: 1, rinbel: 1},
+	}
+
+	report, err := reconcile(trees, direct, idx, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.PaguPohon != 200 || report.Selisih != 100 {
+		t.Errorf("pagu pohon %d selisih %d, want 200 dan 100", report.PaguPohon, report.Selisih)
+	}
+	if len(report.DoubleCounted) != 1 || report.DoubleCounted[0].Dihitung != 2 || len(report.DoubleCounted[0].Nodes) != 2 {
+		t.Errorf("dihitung_ganda = %+v, want R1 di dua node", report.DoubleCounted)
+	}
+}
+
+func TestReconcilePerOpd(t *testing.T) {
+	idx := &pohonIndex{nodes: map[int]pohonEntry{
+		1: {IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik"},
+		2: {IdPohon: 2, Parent: 1, NamaPohon: "Operational A", JenisPohon: "Operational Pemda", Status: statusDisetujui},
+		3: {IdPohon: 3, Parent: 1, NamaPohon: "Operational B", JenisPohon: "Operational Pemda", Status: statusDisetujui},
+	}}
+	trees := [][]PohonKinerjaPemda{{{
+		IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik",
+		Childs: []PohonKinerjaPemda{
+			{IdPohon: 2, RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100}}},
+			{IdPohon: 3, RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R2", KodeSubkegiatan: "2.01.01.2.01.0001", Pagu: 70}}},
+		},
+	}}}
+	direct := map[string]*rekinDirect{
+		"R1": {RekinReconcile: RekinReconcile{IdRekin: "R1", KodeOpd: "OPD1",
+			KodeSubkegiatan: []string{"1.01.01.2.01.0001"}, Anggaran: 100}, idPohon: 2, renaksi: 1, rinbel: 1},
+		"R2": {RekinReconcile: RekinReconcile{IdRekin: "R2", KodeOpd: "OPD2",
+			KodeSubkegiatan: []string{"2.01.01.2.01.0001"}, Anggaran: 80}, idPohon: 3, renaksi: 1, rinbel: 1},
+	}
+
+	report, err := reconcile(trees, direct, idx, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ReconcileOpd{
+		{KodeOpd: "OPD1", PaguPohon: 100, RincianBelanja: 100, Selisih: 0, JumlahRekin: 1},
+		{KodeOpd: "OPD2", PaguPohon: 70, RincianBelanja: 80, Selisih: -10, JumlahRekin: 1},
+	}
+	if !reflect.DeepEqual(report.PerOpd, want) {
+		t.Errorf("per_opd = %+v, want %+v", report.PerOpd, want)
+	}
+
+	// role OPD hanya melihat total OPD-nya
+	report, err = reconcile(trees, direct, idx, 0, "opd2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.PerOpd) != 1 || report.PerOpd[0].KodeOpd != "OPD2" || report.Selisih != -10 {
+		t.Errorf("per_opd OPD2 = %+v selisih %d", report.PerOpd, report.Selisih)
+	}
+}
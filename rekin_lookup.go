@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// RekinNode adalah node pemda beserta rekin yang menempel padanya,
+// dipakai endpoint yang berangkat dari rekin (kode, pegawai) ke pohon.
+type RekinNode struct {
+	IdPohon         int                 `json:"id_pohon"`
+	NamaPohon       string              `json:"nama_pohon"`
+	JenisPohon      JenisPohon          `json:"jenis_pohon"`
+	KodeOpd         string              `json:"kode_opd"`
+	Tematik         PathNode            `json:"tematik"`
+	Path            []PathNode          `json:"path"`
+	Pagu            Pagu                `json:"pagu"`
+	Indikators      []IndikatorPohon    `json:"indikator,omitempty"`
+	RencanaKinerjas []RencanaKinerjaAsn `json:"rencana_kinerjas"`
+}
+
+type TematikPagu struct {
+	Tematik PathNode `json:"tematik"`
+	Pagu    Pagu     `json:"pagu"`
+}
+
+type nodeRekin struct {
+	idPohon int
+	rekin   RencanaKinerjaAsn
+}
+
+// queryRekinNodes mengambil rekin tahun tersebut yang lolos filter beserta
+// pokin pemiliknya. Pagu dihitung sama seperti getRencanaKinerjaPokin,
+// hanya saja rekin tanpa renaksi tetap ikut dengan pagu 0.
+func queryRekinNodes(ctx context.Context, tahun int, filter string, args ...any) ([]nodeRekin, error) {
+	query := `
+		SELECT pokin.id,
+		       rekin.id,
+		       rekin.nama_rencana_kinerja,
+		       pegawai.nama,
+		       pegawai.nip,
+		       keg.kode_kegiatan,
+		       keg.nama_kegiatan,
+		       sub_rekin.kode_subkegiatan,
+		       subkegiatan.nama_subkegiatan,
+		       SUM(rinbel.anggaran) AS total_anggaran
+		FROM tb_rencana_kinerja rekin
+		JOIN tb_pegawai pegawai ON pegawai.nip = rekin.pegawai_id
+		LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
+		LEFT JOIN tb_subkegiatan subkegiatan
+		       ON subkegiatan.kode_subkegiatan = sub_rekin.kode_subkegiatan
+		LEFT JOIN tb_master_kegiatan keg
+		       ON keg.kode_kegiatan = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 12)
+		LEFT JOIN tb_rencana_aksi renaksi
+		       ON renaksi.rencana_kinerja_id = rekin.id
+		LEFT JOIN tb_rincian_belanja rinbel
+		       ON rinbel.renaksi_id = renaksi.id
+		JOIN tb_pohon_kinerja pokin
+		       ON rekin.id_pohon = pokin.id
+		WHERE pokin.tahun = ? AND ` + filter + `
+		GROUP BY pokin.id, rekin.id, rekin.nama_rencana_kinerja,
+		         pegawai.nama, pegawai.nip,
+		         keg.kode_kegiatan, keg.nama_kegiatan,
+		         sub_rekin.kode_subkegiatan, subkegiatan.nama_subkegiatan
+		ORDER BY pokin.id, rekin.id, sub_rekin.kode_subkegiatan
+	`
+
+	rows, err := queryRetry(ctx, query, append([]any{tahun}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var result []nodeRekin
+	for rows.Next() {
+		var nr nodeRekin
+		var kodeKeg, namaKeg, kodeSub, namaSub sql.NullString
+		var totalPagu sql.NullInt64
+		if err := rows.Scan(
+			&nr.idPohon,
+			&nr.rekin.IdRekin,
+			&nr.rekin.RencanaKinerja,
+			&nr.rekin.NamaPelaksana,
+			&nr.rekin.NIPPelaksana,
+			&kodeKeg,
+			&namaKeg,
+			&kodeSub,
+			&namaSub,
+			&totalPagu,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		nr.rekin.KodeKegiatan = kodeKeg.String
+		nr.rekin.NamaKegiatan = namaKeg.String
+		nr.rekin.KodeSubkegiatan = kodeSub.String
+		nr.rekin.NamaSubkegiatan = namaSub.String
+		nr.rekin.Pagu = Pagu(totalPagu.Int64)
+		result = append(result, nr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+// groupRekinNodes mengelompokkan rekin ke node pemda-nya. Rekin di pokin OPD
+// yang tidak terhubung ke Tematik dikembalikan terpisah sebagai unlinked.
+// Pagu node dihitung dengan paguRekin, sama seperti pohon cascading.
+func groupRekinNodes(idx *pohonIndex, rekins []nodeRekin, kodeOpd string) (nodes []RekinNode, tematiks []TematikPagu, total Pagu, unlinked []RencanaKinerjaAsn, err error) {
+	byNode := make(map[int]*RekinNode)
+	var order []int
+
+	for _, nr := range rekins {
+		id := idx.pemdaNodeOf(nr.idPohon)
+		if !idx.visibleTo(id, kodeOpd) {
+			continue
+		}
+		tematik, ok := idx.tematikOf(id)
+		if !ok {
+			unlinked = append(unlinked, nr.rekin)
+			continue
+		}
+		n := byNode[id]
+		if n == nil {
+			e := idx.nodes[id]
+			n = &RekinNode{
+				IdPohon:    id,
+				NamaPohon:  e.NamaPohon,
+				JenisPohon: e.JenisPohon,
+				KodeOpd:    e.KodeOpd,
+				Tematik:    tematik,
+				Path:       idx.path(id),
+			}
+			byNode[id] = n
+			order = append(order, id)
+		}
+		n.RencanaKinerjas = append(n.RencanaKinerjas, nr.rekin)
+	}
+
+	tematikIdx := make(map[int]int)
+	for _, id := range order {
+		n := *byNode[id]
+		if n.Pagu, err = paguRekin(n.RencanaKinerjas); err != nil {
+			return nil, nil, 0, nil, fmt.Errorf("pagu pohon %d: %w", id, err)
+		}
+		nodes = append(nodes, n)
+		if total, err = total.Add(n.Pagu); err != nil {
+			return nil, nil, 0, nil, fmt.Errorf("total pagu: %w", err)
+		}
+
+		i, ok := tematikIdx[n.Tematik.IdPohon]
+		if !ok {
+			i = len(tematiks)
+			tematikIdx[n.Tematik.IdPohon] = i
+			tematiks = append(tematiks, TematikPagu{Tematik: n.Tematik})
+		}
+		if tematiks[i].Pagu, err = tematiks[i].Pagu.Add(n.Pagu); err != nil {
+			return nil, nil, 0, nil, fmt.Errorf("pagu tematik %d: %w", n.Tematik.IdPohon, err)
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Pagu > nodes[j].Pagu })
+	sort.SliceStable(tematiks, func(i, j int) bool { return tematiks[i].Pagu > tematiks[j].Pagu })
+	return nodes, tematiks, total, unlinked, nil
+}
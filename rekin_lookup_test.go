@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestGroupRekinNodesCountsRekinOnce(t *testing.T) {
+	idx := &pohonIndex{nodes: map[int]pohonEntry{
+		1: {IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik"},
+		2: {IdPohon: 2, Parent: 1, NamaPohon: "Operational", JenisPohon: "Operational Pemda", KodeOpd: "OPD1"},
+		// pokin OPD hasil clone dari node 2
+		9: {IdPohon: 9, NamaPohon: "Operational", JenisPohon: "Operational", KodeOpd: "OPD1", CloneFrom: 2},
+	}}
+	rekins := []nodeRekin{
+		{idPohon: 9, rekin: RencanaKinerjaAsn{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 100}},
+		{idPohon: 9, rekin: RencanaKinerjaAsn{IdRekin: "R1", KodeSubkegiatan: "1.01.01.2.01.0002", Pagu: 100}},
+		{idPohon: 9, rekin: RencanaKinerjaAsn{IdRekin: "R2", KodeSubkegiatan: "1.01.01.2.01.0001", Pagu: 30}},
+	}
+
+	nodes, tematiks, total, unlinked, err := groupRekinNodes(idx, rekins, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].IdPohon != 2 || nodes[0].Pagu != 130 {
+		t.Fatalf("nodes = %+v, want node 2 dengan pagu 130", nodes)
+	}
+	if len(nodes[0].RencanaKinerjas) != 3 {
+		t.Errorf("rencana_kinerjas = %d baris, want 3 (satu per subkegiatan)", len(nodes[0].RencanaKinerjas))
+	}
+	if total != 130 || len(tematiks) != 1 || tematiks[0].Pagu != 130 {
+		t.Errorf("total %d, tematiks %+v, want 130", total, tematiks)
+	}
+	if len(unlinked) != 0 {
+		t.Errorf("unlinked = %+v, want kosong", unlinked)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StatusResponse adalah envelope standar untuk respons tanpa data
+// (error, health check), sebentuk dengan CascadingPemda.
+type StatusResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, StatusResponse{Status: status, Message: message})
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sumber teks yang dicari beserta bobotnya, nama pohon paling relevan
+const (
+	matchNamaPohon   = "nama_pohon"
+	matchIndikator   = "indikator"
+	matchRekin       = "rencana_kinerja"
+	matchProgram     = "program"
+	matchKegiatan    = "kegiatan"
+	matchSubkegiatan = "subkegiatan"
+)
+
+var matchWeight = map[string]int{
+	matchNamaPohon:   100,
+	matchIndikator:   80,
+	matchRekin:       60,
+	matchSubkegiatan: 50,
+	matchKegiatan:    45,
+	matchProgram:     40,
+}
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+type SearchMatch struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+}
+
+type SearchResult struct {
+	IdPohon    int           `json:"id_pohon"`
+	NamaPohon  string        `json:"nama_pohon"`
+	JenisPohon JenisPohon    `json:"jenis_pohon"`
+	Tematik    PathNode      `json:"tematik"`
+	Path       []PathNode    `json:"path"`
+	Score      int           `json:"score"`
+	Matches    []SearchMatch `json:"matches"`
+}
+
+type SearchResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Total   int            `json:"total"`
+	Data    []SearchResult `json:"data"`
+}
+
+// ejaan lama yang masih sering muncul di nomenklatur, dinormalkan ke EYD.
+// Hanya dipakai untuk kata yang memuat penanda ejaan lama, karena "nj", "j"
+// dan "oe" juga ada di kata baku (banjir, raja, koefisien). Di kata berejaan
+// lama "j" dibaca "y" (Soerabaja = Surabaya), kecuali sebagai bagian "dj".
+var ejaanLama = strings.NewReplacer("dj", "j", "tj", "c", "nj", "ny", "sj", "sy", "oe", "u", "j", "y")
+
+var ejaanLamaTanda = []string{"dj", "tj", "sj"}
+
+// "oe" baru dianggap penanda bila katanya bukan serapan baku berawalan ko-.
+var ejaanBakuOe = []string{"koefisien", "koeksis", "koersi", "koedukasi", "koevolusi"}
+
+// foldText menyamakan teks untuk pencarian: huruf kecil, diakritik dibuang,
+// tanda baca jadi spasi (anak-anak = anak anak) dan kata berejaan lama
+// dinormalkan.
+func foldText(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		r = stripDiacritic(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	words := strings.Fields(b.String())
+	for i, w := range words {
+		if ejaanLamaWord(w) {
+			words[i] = ejaanLama.Replace(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func ejaanLamaWord(w string) bool {
+	for _, t := range ejaanLamaTanda {
+		if strings.Contains(w, t) {
+			return true
+		}
+	}
+	if !strings.Contains(w, "oe") {
+		return false
+	}
+	for _, baku := range ejaanBakuOe {
+		if strings.HasPrefix(w, baku) {
+			return false
+		}
+	}
+	return true
+}
+
+func stripDiacritic(r rune) rune {
+	switch r {
+	case 'à', 'á', 'â', 'ã', 'ä', 'å':
+		return 'a'
+	case 'è', 'é', 'ê', 'ë':
+		return 'e'
+	case 'ì', 'í', 'î', 'ï':
+		return 'i'
+	case 'ò', 'ó', 'ô', 'õ', 'ö':
+		return 'o'
+	case 'ù', 'ú', 'û', 'ü':
+		return 'u'
+	case 'ç':
+		return 'c'
+	case 'ñ':
+		return 'n'
+	}
+	return r
+}
+
+// searchQuery adalah kata kunci yang sudah dinormalkan.
+type searchQuery struct {
+	phrase string
+	terms  []string
+}
+
+func newSearchQuery(q string) searchQuery {
+	phrase := foldText(q)
+	return searchQuery{phrase: phrase, terms: strings.Fields(phrase)}
+}
+
+// score memberi nilai kecocokan teks, 0 bila tidak semua kata kunci ada.
+// Urutannya: sama persis, frasa utuh di awal, frasa utuh di tengah, lalu
+// semua kata ada; kata yang cocok di awal kata mendapat tambahan.
+func (q searchQuery) score(text string) int {
+	folded := foldText(text)
+	if folded == "" {
+		return 0
+	}
+	for _, t := range q.terms {
+		if !strings.Contains(folded, t) {
+			return 0
+		}
+	}
+
+	score := 0
+	switch {
+	case folded == q.phrase:
+		score = 50
+	case strings.HasPrefix(folded, q.phrase):
+		score = 30
+	case strings.Contains(folded, q.phrase):
+		score = 20
+	}
+
+	words := strings.Fields(folded)
+	for _, t := range q.terms {
+		for _, w := range words {
+			if strings.HasPrefix(w, t) {
+				score += 5
+				break
+			}
+		}
+	}
+	return score
+}
+
+type searchHit struct {
+	node  int
+	field string
+	text  string
+}
+
+// searchCandidates membaca semua teks yang bisa dicari untuk satu tahun.
+// Pencocokan dilakukan di Go supaya case folding tidak bergantung pada
+// collation database.
+func searchCandidates(ctx context.Context, idx *pohonIndex, tahun int) ([]searchHit, error) {
+	var hits []searchHit
+	for id, e := range idx.nodes {
+		hits = append(hits, searchHit{node: id, field: matchNamaPohon, text: e.NamaPohon})
+	}
+
+	rows, err := queryRetry(ctx, `SELECT pokin_id, indikator FROM tb_indikator WHERE tahun = ? AND pokin_id IS NOT NULL`, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query indikator error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pokinId, indikator sql.NullString
+		if err := rows.Scan(&pokinId, &indikator); err != nil {
+			return nil, fmt.Errorf("scan indikator error: %w", err)
+		}
+		// pokin_id berupa teks dan bisa kosong atau bukan angka, baris seperti itu dilewati
+		idPohon, err := strconv.Atoi(strings.TrimSpace(pokinId.String))
+		if err != nil {
+			continue
+		}
+		hits = append(hits, searchHit{node: idx.pemdaNodeOf(idPohon), field: matchIndikator, text: indikator.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// rekin menempel di pokin OPD hasil clone, node pemda-nya dicari lewat index
+	rekinRows, err := queryRetry(ctx, `
+		SELECT rekin.id_pohon,
+		       rekin.nama_rencana_kinerja,
+		       subkegiatan.nama_subkegiatan,
+		       keg.nama_kegiatan,
+		       prog.nama_program
+		FROM tb_rencana_kinerja rekin
+		JOIN tb_pohon_kinerja pokin ON pokin.id = rekin.id_pohon
+		LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
+		LEFT JOIN tb_subkegiatan subkegiatan
+		       ON subkegiatan.kode_subkegiatan = sub_rekin.kode_subkegiatan
+		LEFT JOIN tb_master_kegiatan keg
+		       ON keg.kode_kegiatan = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 12)
+		LEFT JOIN tb_master_program prog
+		       ON prog.kode_program = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 7)
+		WHERE pokin.tahun = ?`, tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query rekin error: %w", err)
+	}
+	defer rekinRows.Close()
+	for rekinRows.Next() {
+		var idPohon int
+		var rekin, sub, keg, prog sql.NullString
+		if err := rekinRows.Scan(&idPohon, &rekin, &sub, &keg, &prog); err != nil {
+			return nil, fmt.Errorf("scan rekin error: %w", err)
+		}
+		node := idx.pemdaNodeOf(idPohon)
+		hits = append(hits,
+			searchHit{node: node, field: matchRekin, text: rekin.String},
+			searchHit{node: node, field: matchSubkegiatan, text: sub.String},
+			searchHit{node: node, field: matchKegiatan, text: keg.String},
+			searchHit{node: node, field: matchProgram, text: prog.String},
+		)
+	}
+	if err := rekinRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return hits, nil
+}
+
+// searchPohon mengelompokkan kecocokan per node. Skor node adalah skor
+// terbaik ditambah sedikit untuk tiap sumber lain yang juga cocok.
+func searchPohon(idx *pohonIndex, hits []searchHit, q searchQuery, kodeOpd string) []SearchResult {
+	type agg struct {
+		best    int
+		extra   int
+		matches []SearchMatch
+		seen    map[string]bool
+	}
+	byNode := make(map[int]*agg)
+
+	for _, h := range hits {
+		s := q.score(h.text)
+		if s == 0 {
+			continue
+		}
+		a := byNode[h.node]
+		if a == nil {
+			a = &agg{seen: make(map[string]bool)}
+			byNode[h.node] = a
+		}
+		key := h.field + "\x00" + h.text
+		if a.seen[key] {
+			continue
+		}
+		a.seen[key] = true
+
+		s += matchWeight[h.field]
+		if s > a.best {
+			a.extra += a.best / 10
+			a.best = s
+		} else {
+			a.extra += s / 10
+		}
+		a.matches = append(a.matches, SearchMatch{Field: h.field, Text: h.text})
+	}
+
+	var results []SearchResult
+	for id, a := range byNode {
+		tematik, ok := idx.tematikOf(id)
+		if !ok || !idx.visibleTo(id, kodeOpd) {
+			continue
+		}
+		e := idx.nodes[id]
+		sort.SliceStable(a.matches, func(i, j int) bool {
+			return matchWeight[a.matches[i].Field] > matchWeight[a.matches[j].Field]
+		})
+		results = append(results, SearchResult{
+			IdPohon:    id,
+			NamaPohon:  e.NamaPohon,
+			JenisPohon: e.JenisPohon,
+			Tematik:    tematik,
+			Path:       idx.path(id),
+			Score:      a.best + a.extra,
+			Matches:    a.matches,
+		})
+	}
+
+	// skor tertinggi dulu, lalu node yang lebih dekat ke Tematik
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if len(results[i].Path) != len(results[j].Path) {
+			return len(results[i].Path) < len(results[j].Path)
+		}
+		return results[i].IdPohon < results[j].IdPohon
+	})
+	return results
+}
+
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tahun, err := paramInt(r.URL.Query(), "tahun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?q=stunting&tahun=2025")
+		return
+	}
+
+	q := newSearchQuery(r.URL.Query().Get("q"))
+	if len(q.terms) == 0 {
+		writeError(w, http.StatusBadRequest, "params q is required, misal: ?q=stunting&tahun=2025")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit, antara 1 dan %d", maxSearchLimit))
+			return
+		}
+	}
+
+	idx, err := loadPohonIndex(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	hits, err := searchCandidates(ctx, idx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := searchPohon(idx, hits, q, restrictedOpd(r.Context()))
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	writeLaporan(w, r, SearchResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Pencarian %q Tahun %d", r.URL.Query().Get("q"), tahun),
+		Total:   total,
+		Data:    results,
+	})
+}
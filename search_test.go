@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestFoldText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Anak-Anak  Sekolah", "anak anak sekolah"},
+		{"Penanggulangan Bandjir", "penanggulangan banjir"},
+		{"Penanggulangan Banjir", "penanggulangan banjir"},
+		{"Pendjagaan Pantai", "penjagaan pantai"},
+		{"Soerabaja", "surabaya"},
+		{"Djakarta Raja", "jakarta raja"},
+		{"Djokjakarta", "jokyakarta"},
+		{"Koefisien Gini", "koefisien gini"},
+		{"Koersif", "koersif"},
+		{"Kantor Raja", "kantor raja"},
+		{"Tjiliwoeng", "ciliwung"},
+		{"Café Résumé", "cafe resume"},
+	}
+	for _, tt := range tests {
+		if got := foldText(tt.in); got != tt.want {
+			t.Errorf("foldText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQueryScoreEjaan(t *testing.T) {
+	tests := []struct {
+		q, text string
+	}{
+		{"banjir", "Penanggulangan Bandjir"},
+		{"bandjir", "Penanggulangan Banjir"},
+		{"banjir", "Penanggulangan Banjir"},
+		{"koefisien", "Koefisien Gini"},
+		{"surabaya", "Kota Soerabaja"},
+		{"jakarta", "Djakarta Raja"},
+	}
+	for _, tt := range tests {
+		if newSearchQuery(tt.q).score(tt.text) == 0 {
+			t.Errorf("%q harus cocok dengan %q", tt.q, tt.text)
+		}
+	}
+	if newSearchQuery("banyir").score("Penanggulangan Banjir") != 0 {
+		t.Error("banyir tidak boleh cocok dengan banjir")
+	}
+	if newSearchQuery("kufisien").score("Koefisien Gini") != 0 {
+		t.Error("kufisien tidak boleh cocok dengan koefisien")
+	}
+}
+
+func TestSearchPohonMapsCloneToPemda(t *testing.T) {
+	idx := &pohonIndex{nodes: map[int]pohonEntry{
+		1: {IdPohon: 1, NamaPohon: "Tematik", JenisPohon: "Tematik"},
+		2: {IdPohon: 2, Parent: 1, NamaPohon: "Strategic", JenisPohon: "Strategic Pemda", LevelPohon: 4},
+		// pokin OPD hasil clone dari node 2
+		9: {IdPohon: 9, NamaPohon: "Strategic", JenisPohon: "Strategic", LevelPohon: 4, CloneFrom: 2},
+	}}
+	hits := []searchHit{{node: idx.pemdaNodeOf(9), field: matchIndikator, text: "Prevalensi stunting"}}
+
+	results := searchPohon(idx, hits, newSearchQuery("stunting"), "")
+	if len(results) != 1 || results[0].IdPohon != 2 || results[0].Tematik.IdPohon != 1 {
+		t.Fatalf("hasil = %+v, want node pemda 2 di tematik 1", results)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// requestStats menghitung request yang sedang diproses dan yang sudah selesai,
+// dipakai untuk ringkasan saat shutdown.
+type requestStats struct {
+	inFlight atomic.Int64
+	served   atomic.Int64
+}
+
+func (s *requestStats) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer func() {
+			s.inFlight.Add(-1)
+			s.served.Add(1)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newServer(c Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              c.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(c.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(c.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(c.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(c.Server.IdleTimeout),
+	}
+}
+
+// runServer menjalankan server sampai menerima SIGINT/SIGTERM, lalu menunggu
+// request yang sedang berjalan selesai (maksimal shutdownTimeout) dan menutup db.
+func runServer(srv *http.Server, stats *requestStats, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// sinyal kedua kembali ke perilaku bawaan, langsung menghentikan proses
+	context.AfterFunc(ctx, stop)
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return serveUntil(ctx, srv, ln, stats, shutdownTimeout)
+}
+
+// serveUntil melayani request di ln sampai ctx selesai, lalu shutdown bersih.
+func serveUntil(ctx context.Context, srv *http.Server, ln net.Listener, stats *requestStats, shutdownTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server running di %s", ln.Addr())
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	started := time.Now()
+	pending := stats.inFlight.Load()
+	log.Printf("Sinyal shutdown diterima, menunggu %d request selesai (maks %s)", pending, shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	shutdownErr := srv.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		log.Printf("[WARN] shutdown tidak bersih: %v", shutdownErr)
+		srv.Close()
+	}
+
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Printf("[WARN] gagal menutup database: %v", err)
+		}
+	}
+
+	log.Printf("Shutdown selesai dalam %s: total request dilayani %d, in-flight saat sinyal %d, terputus %d",
+		time.Since(started).Round(time.Millisecond), stats.served.Load(), pending, stats.inFlight.Load())
+
+	return shutdownErr
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startServer menjalankan serveUntil dengan handler yang menahan request
+// sampai release ditutup, lalu menunggu satu request benar-benar in-flight.
+func startServer(t *testing.T, shutdownTimeout time.Duration) (cancel context.CancelFunc, release chan struct{}, resp chan error, done chan error, stats *requestStats) {
+	t.Helper()
+	saved := db
+	db = nil
+	t.Cleanup(func() { db = saved })
+
+	release = make(chan struct{})
+	stats = &requestStats{}
+	handler := stats.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		writeJSON(w, http.StatusOK, StatusResponse{Status: http.StatusOK, Message: "selesai"})
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := defaultConfig()
+	srv := newServer(c, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	done = make(chan error, 1)
+	go func() { done <- serveUntil(ctx, srv, ln, stats, shutdownTimeout) }()
+
+	resp = make(chan error, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err == nil {
+			_, err = io.ReadAll(res.Body)
+			res.Body.Close()
+			if err == nil && res.StatusCode != http.StatusOK {
+				err = errors.New(res.Status)
+			}
+		}
+		resp <- err
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for stats.inFlight.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("request tidak pernah sampai ke handler")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return cancel, release, resp, done, stats
+}
+
+func TestServeUntilDrainsInFlight(t *testing.T) {
+	cancel, release, resp, done, stats := startServer(t, 5*time.Second)
+
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("server berhenti sebelum request selesai: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-resp; err != nil {
+		t.Errorf("request in-flight gagal: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("shutdown error = %v, want nil", err)
+	}
+	if stats.served.Load() != 1 || stats.inFlight.Load() != 0 {
+		t.Errorf("served %d in-flight %d, want 1 dan 0", stats.served.Load(), stats.inFlight.Load())
+	}
+}
+
+func TestServeUntilShutdownTimeout(t *testing.T) {
+	cancel, release, resp, done, _ := startServer(t, 50*time.Millisecond)
+	defer close(release)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("shutdown error = %v, want DeadlineExceeded", err)
+	}
+	// request yang melewati batas diputus
+	if err := <-resp; err == nil {
+		t.Error("request yang melewati shutdown_timeout harus terputus")
+	}
+}
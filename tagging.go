@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TaggedNode struct {
+	NamaTagging       string           `json:"nama_tagging"`
+	KeteranganTagging string           `json:"keterangan_tagging"`
+	InheritedFrom     int              `json:"inherited_from,omitempty"`
+	IdPohon           int              `json:"id_pohon"`
+	NamaPohon         string           `json:"nama_pohon"`
+	JenisPohon        JenisPohon       `json:"jenis_pohon"`
+	KodeOpd           string           `json:"kode_opd,omitempty"`
+	Tematik           PathNode         `json:"tematik"`
+	Path              []PathNode       `json:"path"`
+	Pagu              Pagu             `json:"pagu"`
+	Programs          []Program        `json:"program"`
+	Indikators        []IndikatorPohon `json:"indikator"`
+}
+
+// TaggingTotal menjumlahkan pagu per tagging. Node bertagging yang berada
+// di bawah node lain dengan tagging sama tidak dihitung dua kali.
+type TaggingTotal struct {
+	NamaTagging string `json:"nama_tagging"`
+	JumlahNode  int    `json:"jumlah_node"`
+	Pagu        Pagu   `json:"pagu"`
+}
+
+type TaggingReport struct {
+	Tahun  int            `json:"tahun"`
+	Totals []TaggingTotal `json:"totals"`
+	Nodes  []TaggedNode   `json:"nodes"`
+	// tematik yang gagal dibangun, laporan tetap dikirim tanpa tematik ini
+	Errors []BatchResult `json:"errors,omitempty"`
+}
+
+type TaggingResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    TaggingReport `json:"data"`
+}
+
+// taggedTematiks mencari Tematik yang memuat node bertagging di tahun itu,
+// supaya hanya pohon yang relevan yang dibangun.
+func taggedTematiks(ctx context.Context, idx *pohonIndex, namaTagging string) ([]int, error) {
+	rows, err := queryRetry(ctx, `SELECT tg.id_pokin, tg.nama_tagging
+		FROM tb_tagging_pokin tg
+		JOIN tb_pohon_kinerja pokin ON pokin.id = tg.id_pokin
+		WHERE pokin.tahun = ?`, idx.tahun)
+	if err != nil {
+		return nil, fmt.Errorf("query tagging error: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int]bool)
+	var ids []int
+	for rows.Next() {
+		var idPokin int
+		var nama string
+		if err := rows.Scan(&idPokin, &nama); err != nil {
+			return nil, fmt.Errorf("scan tagging error: %w", err)
+		}
+		if namaTagging != "" && !sameTagging(nama, namaTagging) {
+			continue
+		}
+		tematik, ok := idx.tematikOf(idPokin)
+		if ok && !seen[tematik.IdPohon] {
+			seen[tematik.IdPohon] = true
+			ids = append(ids, tematik.IdPohon)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func sameTagging(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// subtreePrograms mengumpulkan program dari kegiatan rekin di seluruh
+// subtree, dengan cara yang sama seperti program di node Tactical.
+func subtreePrograms(ctx context.Context, node PohonKinerjaPemda, seen map[string]bool, programs []Program) ([]Program, error) {
+	for _, rekin := range node.RencanaKinerjas {
+		if len(rekin.KodeKegiatan) < kodeProgramLen {
+			continue
+		}
+		prog, err := getProgramFromKegiatan(ctx, rekin.KodeKegiatan)
+		if err != nil {
+			return nil, fmt.Errorf("program kegiatan %s: %w", rekin.KodeKegiatan, err)
+		}
+		// kode yang tidak ada di master program dilewati
+		if prog.KodeProgram == "" || seen[prog.KodeProgram] {
+			continue
+		}
+		seen[prog.KodeProgram] = true
+		programs = append(programs, Program{KodeProgram: prog.KodeProgram, NamaProgram: prog.NamaProgram})
+	}
+	for _, child := range node.Childs {
+		var err error
+		if programs, err = subtreePrograms(ctx, child, seen, programs); err != nil {
+			return nil, err
+		}
+	}
+	return programs, nil
+}
+
+// collectTagged menelusuri pohon dan mencatat node yang bertagging,
+// termasuk tagging turunan bila inherit_tagging aktif. covered berisi
+// tagging yang sudah dihitung di leluhur.
+func collectTagged(ctx context.Context, nodes []PohonKinerjaPemda, path []PathNode, namaTagging string, covered map[string]bool, report *TaggingReport, totals map[string]*TaggingTotal) error {
+	for _, node := range nodes {
+		nodePath := append(path[:len(path):len(path)], PathNode{IdPohon: node.IdPohon, NamaPohon: node.NamaPohon, JenisPohon: node.JenisPohon})
+
+		childCovered, copied := covered, false
+		for _, tag := range node.Tagging {
+			if namaTagging != "" && !sameTagging(tag.NamaTagging, namaTagging) {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(tag.NamaTagging))
+			programs, err := subtreePrograms(ctx, node, make(map[string]bool), nil)
+			if err != nil {
+				return fmt.Errorf("pohon %d: %w", node.IdPohon, err)
+			}
+
+			report.Nodes = append(report.Nodes, TaggedNode{
+				NamaTagging:       tag.NamaTagging,
+				KeteranganTagging: tag.KeteranganTagging,
+				InheritedFrom:     tag.InheritedFrom,
+				IdPohon:           node.IdPohon,
+				NamaPohon:         node.NamaPohon,
+				JenisPohon:        node.JenisPohon,
+				KodeOpd:           node.KodeOpd,
+				Tematik:           nodePath[0],
+				Path:              nodePath,
+				Pagu:              node.Pagu,
+				Programs:          programs,
+				Indikators:        node.Indikators,
+			})
+
+			total := totals[key]
+			if total == nil {
+				total = &TaggingTotal{NamaTagging: tag.NamaTagging}
+				totals[key] = total
+			}
+			total.JumlahNode++
+			if !covered[key] {
+				pagu, err := total.Pagu.Add(node.Pagu)
+				if err != nil {
+					return fmt.Errorf("pagu tagging %s: %w", tag.NamaTagging, err)
+				}
+				total.Pagu = pagu
+				if !copied {
+					childCovered = maps.Clone(covered)
+					copied = true
+				}
+				childCovered[key] = true
+			}
+		}
+
+		if err := collectTagged(ctx, node.Childs, nodePath, namaTagging, childCovered, report, totals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func taggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tahun, err := paramInt(r.URL.Query(), "tahun")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error()+", misal: ?tahun=2025&nama_tagging=Stunting")
+		return
+	}
+	// nama_tagging kosong berarti semua tagging
+	namaTagging := strings.TrimSpace(r.URL.Query().Get("nama_tagging"))
+
+	// rekin tetap dimuat untuk program subtree, sisanya cukup indikator dan tagging
+	opts := optionsWithout(partSasaran, partTujuan, partUrusan, partBidangUrusan, partKegiatan, partSubkegiatan)
+	if v := r.URL.Query().Get("inherit_tagging"); v != "" {
+		opts.InheritTagging, err = strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid inherit_tagging, pakai true/false")
+			return
+		}
+	}
+
+	idx, err := loadPohonIndex(ctx, tahun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tematikIds, err := taggedTematiks(ctx, idx, namaTagging)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	kodeOpd := restrictedOpd(r.Context())
+	trees := make([][]PohonKinerjaPemda, len(tematikIds))
+	var mu sync.Mutex
+	var failed []BatchResult
+
+	forEachTematik(tematikIds, func(i, tematikId int) {
+		var result BatchResult
+		if !builds.acquire(r.Context()) {
+			result = BatchResult{TematikId: tematikId, Status: http.StatusTooManyRequests,
+				Error: "server sedang sibuk membangun laporan, coba lagi nanti"}
+		} else {
+			list, err := buildTematik(ctx, tematikId, tahun, opts)
+			builds.release()
+			if err == nil && kodeOpd != "" {
+				list, _, err = restrictTreeToOpd(list, kodeOpd)
+			}
+			if err == nil {
+				trees[i] = list
+				return
+			}
+			result = BatchResult{TematikId: tematikId, Status: http.StatusInternalServerError, Error: err.Error()}
+		}
+		mu.Lock()
+		failed = append(failed, result)
+		mu.Unlock()
+	})
+
+	report := TaggingReport{Tahun: tahun, Errors: failed}
+	totals := make(map[string]*TaggingTotal)
+	for _, list := range trees {
+		if err := collectTagged(ctx, list, nil, namaTagging, map[string]bool{}, &report, totals); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	for _, t := range totals {
+		report.Totals = append(report.Totals, *t)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Pagu > report.Totals[j].Pagu })
+
+	message := fmt.Sprintf("Laporan Tagging Tahun %d", tahun)
+	if namaTagging != "" {
+		message = fmt.Sprintf("Laporan Tagging %s Tahun %d", namaTagging, tahun)
+	}
+	writeLaporan(w, r, TaggingResponse{
+		Status:  http.StatusOK,
+		Message: message,
+		Data:    report,
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func tagged(nama string) []TaggingPokin {
+	return []TaggingPokin{{NamaTagging: nama}}
+}
+
+func TestCollectTaggedCoveredSubtree(t *testing.T) {
+	useFakeDB(t, fakeQueryContains(nil))
+	tree := []PohonKinerjaPemda{{
+		IdPohon: 1, NamaPohon: "Tematik", Pagu: 1000,
+		Childs: []PohonKinerjaPemda{
+			{
+				IdPohon: 2, NamaPohon: "Strategic", Pagu: 600, Tagging: tagged("Stunting"),
+				Childs: []PohonKinerjaPemda{
+					// sudah tercakup leluhur dengan tagging sama
+					{IdPohon: 3, NamaPohon: "Tactical", Pagu: 400, Tagging: tagged(" stunting ")},
+					{IdPohon: 4, NamaPohon: "Tactical", Pagu: 200, Tagging: tagged("Kemiskinan")},
+				},
+			},
+			{IdPohon: 5, NamaPohon: "Strategic", Pagu: 400, Tagging: tagged("Stunting")},
+		},
+	}}
+
+	var report TaggingReport
+	totals := make(map[string]*TaggingTotal)
+	if err := collectTagged(context.Background(), tree, nil, "", map[string]bool{}, &report, totals); err != nil {
+		t.Fatal(err)
+	}
+	if got := totals["stunting"]; got == nil || got.Pagu != 1000 || got.JumlahNode != 3 {
+		t.Errorf("total stunting = %+v, want pagu 1000 dari 3 node", got)
+	}
+	if got := totals["kemiskinan"]; got == nil || got.Pagu != 200 || got.JumlahNode != 1 {
+		t.Errorf("total kemiskinan = %+v, want pagu 200", got)
+	}
+	if len(report.Nodes) != 4 {
+		t.Fatalf("nodes = %d, want 4", len(report.Nodes))
+	}
+	if n := report.Nodes[1]; n.IdPohon != 3 || n.Tematik.IdPohon != 1 || len(n.Path) != 3 {
+		t.Errorf("node 3 = %+v", n)
+	}
+
+	// filter nama_tagging, cakupan tetap per tagging
+	report = TaggingReport{}
+	totals = make(map[string]*TaggingTotal)
+	if err := collectTagged(context.Background(), tree, nil, "KEMISKINAN", map[string]bool{}, &report, totals); err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 1 || totals["kemiskinan"].Pagu != 200 || len(report.Nodes) != 1 {
+		t.Errorf("filter kemiskinan: totals %+v nodes %+v", totals, report.Nodes)
+	}
+}
+
+func TestCollectTaggedPrograms(t *testing.T) {
+	useFakeDB(t, fakeQueryContains(map[string]fakeResult{
+		"FROM tb_master_program": fakeAnswer([]driver.Value{"1.01.01", "Program A"}),
+	}))
+	tree := []PohonKinerjaPemda{{
+		IdPohon: 1, Tagging: tagged("Stunting"),
+		RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01"}},
+		Childs: []PohonKinerjaPemda{{
+			IdPohon: 2, RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R2", KodeKegiatan: "1.01.01.2.02"},
+				// kode pendek tidak punya program
+				{IdRekin: "R3", KodeKegiatan: "1.01"},
+			},
+		}},
+	}}
+
+	var report TaggingReport
+	if err := collectTagged(context.Background(), tree, nil, "", map[string]bool{}, &report, map[string]*TaggingTotal{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Nodes) != 1 || len(report.Nodes[0].Programs) != 1 || report.Nodes[0].Programs[0].NamaProgram != "Program A" {
+		t.Errorf("program = %+v, want satu Program A", report.Nodes)
+	}
+}
+
+func TestCollectTaggedProgramError(t *testing.T) {
+	errDB := errors.New("koneksi putus")
+	useFakeDB(t, fakeQueryContains(map[string]fakeResult{
+		"FROM tb_master_program": {err: errDB},
+	}))
+	tree := []PohonKinerjaPemda{{
+		IdPohon: 1, Tagging: tagged("Stunting"),
+		RencanaKinerjas: []RencanaKinerjaAsn{{IdRekin: "R1", KodeKegiatan: "1.01.01.2.01"}},
+	}}
+
+	var report TaggingReport
+	err := collectTagged(context.Background(), tree, nil, "", map[string]bool{}, &report, map[string]*TaggingTotal{})
+	if !errors.Is(err, errDB) {
+		t.Errorf("err = %v, want error master program", err)
+	}
+}
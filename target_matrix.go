@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sumber indikator di matriks
+const (
+	sumberPohon       = "pohon"
+	sumberProgram     = "program"
+	sumberKegiatan    = "kegiatan"
+	sumberSubkegiatan = "subkegiatan"
+)
+
+// TargetCell adalah target satu tahun. Tahun tanpa target tetap ada
+// dengan Target null dan Terisi false.
+type TargetCell struct {
+	Tahun  int      `json:"tahun"`
+	Target *string  `json:"target"`
+	Nilai  *float64 `json:"nilai"`
+	Satuan string   `json:"satuan,omitempty"`
+	Terisi bool     `json:"terisi"`
+}
+
+type TargetRow struct {
+	IdIndikator string       `json:"id_indikator"`
+	Indikator   string       `json:"indikator"`
+	Kode        string       `json:"kode,omitempty"`
+	Sumber      string       `json:"sumber"`
+	IdPohon     int          `json:"id_pohon"`
+	NamaPohon   string       `json:"nama_pohon"`
+	JenisPohon  JenisPohon   `json:"jenis_pohon"`
+	Level       int          `json:"level"`
+	Satuan      string       `json:"satuan"`
+	Targets     []TargetCell `json:"targets"`
+	// jumlah tahun periode yang belum ada targetnya
+	Kosong int `json:"kosong"`
+}
+
+type TargetMatrix struct {
+	Tematik PathNode      `json:"tematik"`
+	Periode PeriodeTujuan `json:"periode"`
+	Tahun   []int         `json:"tahun"`
+	Rows    []TargetRow   `json:"rows"`
+}
+
+type TargetMatrixResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    TargetMatrix `json:"data"`
+}
+
+func (p PeriodeTujuan) years() ([]int, error) {
+	awal, errAwal := strconv.Atoi(strings.TrimSpace(p.TahunAwal))
+	akhir, errAkhir := strconv.Atoi(strings.TrimSpace(p.TahunAkhir))
+	if errAwal != nil || errAkhir != nil || awal > akhir || akhir-awal > 20 {
+		return nil, fmt.Errorf("periode %s-%s tidak valid", p.TahunAwal, p.TahunAkhir)
+	}
+	var years []int
+	for y := awal; y <= akhir; y++ {
+		years = append(years, y)
+	}
+	return years, nil
+}
+
+// treePeriode mengambil periode dari tujuan pemda Tematik, lalu sasaran
+// pemda di bawahnya. Keduanya memakai tb_periode yang sama.
+func treePeriode(nodes []PohonKinerjaPemda) (PeriodeTujuan, bool) {
+	for _, n := range nodes {
+		for _, tuj := range n.TujuanPemda {
+			if _, err := tuj.Periode.years(); err == nil {
+				return tuj.Periode, true
+			}
+		}
+		for _, sas := range n.SasaranPemda {
+			if _, err := sas.Periode.years(); err == nil {
+				return sas.Periode, true
+			}
+		}
+		if p, ok := treePeriode(n.Childs); ok {
+			return p, true
+		}
+	}
+	return PeriodeTujuan{}, false
+}
+
+// getPeriodeByTahun mencari periode RPJMD yang memuat tahun.
+func getPeriodeByTahun(ctx context.Context, tahun int) (PeriodeTujuan, bool, error) {
+	var p PeriodeTujuan
+	err := withRetry(ctx, cfg.DB, func() error {
+		return db.QueryRowContext(ctx, `SELECT tahun_awal, tahun_akhir, jenis_periode
+			FROM tb_periode
+			WHERE CAST(tahun_awal AS UNSIGNED) <= ? AND CAST(tahun_akhir AS UNSIGNED) >= ?
+			ORDER BY CAST(tahun_awal AS UNSIGNED) DESC LIMIT 1`, tahun, tahun).
+			Scan(&p.TahunAwal, &p.TahunAkhir, &p.JenisPeriode)
+	})
+	if err == sql.ErrNoRows {
+		return p, false, nil
+	}
+	if err != nil {
+		return p, false, fmt.Errorf("query periode error: %w", err)
+	}
+	return p, true, nil
+}
+
+func newTargetRow(ind IndikatorPohon, sumber string, node PohonKinerjaPemda, level int, years []int) TargetRow {
+	row := TargetRow{
+		IdIndikator: ind.IdIndikator,
+		Indikator:   ind.Indikator,
+		Kode:        ind.Kode,
+		Sumber:      sumber,
+		IdPohon:     node.IdPohon,
+		NamaPohon:   node.NamaPohon,
+		JenisPohon:  node.JenisPohon,
+		Level:       level,
+	}
+
+	byTahun := make(map[int]TargetIndikator)
+	for _, t := range ind.Target {
+		if _, ok := byTahun[t.Tahun]; !ok {
+			byTahun[t.Tahun] = t
+		}
+		if row.Satuan == "" {
+			row.Satuan = t.Satuan
+		}
+	}
+
+	for _, y := range years {
+		cell := TargetCell{Tahun: y}
+		if t, ok := byTahun[y]; ok {
+			target := t.Target
+			cell.Target = &target
+			cell.Nilai = t.Nilai
+			cell.Satuan = t.Satuan
+			cell.Terisi = true
+		} else {
+			row.Kosong++
+		}
+		row.Targets = append(row.Targets, cell)
+	}
+	return row
+}
+
+// collectTargetRows menelusuri pohon pre-order. Indikator yang sama
+// (misal indikator kegiatan di beberapa rekin) hanya muncul sekali.
+func collectTargetRows(nodes []PohonKinerjaPemda, level int, years []int, seen map[string]bool, rows []TargetRow) []TargetRow {
+	add := func(ind IndikatorPohon, sumber string, node PohonKinerjaPemda) {
+		if seen[ind.IdIndikator] {
+			return
+		}
+		seen[ind.IdIndikator] = true
+		rows = append(rows, newTargetRow(ind, sumber, node, level, years))
+	}
+
+	for _, n := range nodes {
+		for _, ind := range n.Indikators {
+			add(ind, sumberPohon, n)
+		}
+		for _, prog := range n.ProgramPokin {
+			for _, ind := range prog.IndikatorProgram {
+				add(ind, sumberProgram, n)
+			}
+		}
+		for _, rekin := range n.RencanaKinerjas {
+			for _, ind := range rekin.IndikatorKegiatan {
+				add(ind, sumberKegiatan, n)
+			}
+			for _, ind := range rekin.IndikatorSubkegiatan {
+				add(ind, sumberSubkegiatan, n)
+			}
+		}
+		rows = collectTargetRows(n.Childs, level+1, years, seen, rows)
+	}
+	return rows
+}
+
+func targetMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	tematikId, tahun, err := tematikTahunParams(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// periode bisa dipaksa lewat ?tahun_awal=&tahun_akhir=
+	periode := PeriodeTujuan{TahunAwal: q.Get("tahun_awal"), TahunAkhir: q.Get("tahun_akhir")}
+	override := periode.TahunAwal != "" || periode.TahunAkhir != ""
+	if override {
+		if _, err := periode.years(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	opts := optionsWithout(partTagging, partUrusan, partBidangUrusan, partKegiatan, partSubkegiatan)
+	list, err := buildTematik(ctx, tematikId, tahun, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(list) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("tematik %d tidak ditemukan untuk tahun %d", tematikId, tahun))
+		return
+	}
+	if kodeOpd := restrictedOpd(r.Context()); kodeOpd != "" {
+		if list, _, err = restrictTreeToOpd(list, kodeOpd); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if !override {
+		var ok bool
+		periode, ok = treePeriode(list)
+		if !ok {
+			periode, ok, err = getPeriodeByTahun(ctx, tahun)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if !ok {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("periode untuk tahun %d tidak ditemukan, isi tahun_awal dan tahun_akhir", tahun))
+				return
+			}
+		}
+	}
+	years, err := periode.years()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	matrix := TargetMatrix{
+		Periode: periode,
+		Tahun:   years,
+		Rows:    collectTargetRows(list, 1, years, make(map[string]bool), nil),
+	}
+	if len(list) > 0 {
+		matrix.Tematik = PathNode{IdPohon: list[0].IdPohon, NamaPohon: list[0].NamaPohon, JenisPohon: list[0].JenisPohon}
+	}
+
+	writeLaporan(w, r, TargetMatrixResponse{
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Matriks Target Indikator %s-%s", periode.TahunAwal, periode.TahunAkhir),
+		Data:    matrix,
+	})
+}
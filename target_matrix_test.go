@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPeriodeYears(t *testing.T) {
+	years, err := PeriodeTujuan{TahunAwal: "2025", TahunAkhir: " 2029"}.years()
+	if err != nil || !slices.Equal(years, []int{2025, 2026, 2027, 2028, 2029}) {
+		t.Errorf("years = %v, %v", years, err)
+	}
+	for _, p := range []PeriodeTujuan{
+		{TahunAwal: "2029", TahunAkhir: "2025"},
+		{TahunAwal: "", TahunAkhir: "2025"},
+		{TahunAwal: "2000", TahunAkhir: "2030"},
+	} {
+		if _, err := p.years(); err == nil {
+			t.Errorf("periode %+v harus error", p)
+		}
+	}
+}
+
+func TestTreePeriode(t *testing.T) {
+	rpjmd := PeriodeTujuan{TahunAwal: "2025", TahunAkhir: "2029", JenisPeriode: "RPJMD"}
+	rpd := PeriodeTujuan{TahunAwal: "2024", TahunAkhir: "2026", JenisPeriode: "RPD"}
+	rusak := PeriodeTujuan{TahunAwal: "2029", TahunAkhir: "2025"}
+
+	tests := []struct {
+		name string
+		tree []PohonKinerjaPemda
+		want PeriodeTujuan
+		ok   bool
+	}{
+		{"tujuan tematik didahulukan", []PohonKinerjaPemda{{
+			TujuanPemda:  []TujuanPemda{{Periode: rpjmd}},
+			SasaranPemda: []SasaranPemda{{Periode: rpd}},
+		}}, rpjmd, true},
+		{"tujuan tidak valid dilewati", []PohonKinerjaPemda{{
+			TujuanPemda:  []TujuanPemda{{Periode: rusak}},
+			SasaranPemda: []SasaranPemda{{Periode: rpd}},
+		}}, rpd, true},
+		{"sasaran di subtema", []PohonKinerjaPemda{{
+			Childs: []PohonKinerjaPemda{{SasaranPemda: []SasaranPemda{{Periode: rpd}}}},
+		}}, rpd, true},
+		{"tanpa periode", []PohonKinerjaPemda{{Childs: []PohonKinerjaPemda{{}}}}, PeriodeTujuan{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := treePeriode(tt.tree)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: treePeriode = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGetPeriodeByTahun(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		if strings.Contains(query, "FROM tb_periode") && args[0].(int64) == 2026 {
+			return fakeAnswer([]driver.Value{"2025", "2029", "RPJMD"})
+		}
+		return fakeResult{cols: []string{"tahun_awal", "tahun_akhir", "jenis_periode"}}
+	})
+
+	p, ok, err := getPeriodeByTahun(context.Background(), 2026)
+	if err != nil || !ok || p.TahunAwal != "2025" || p.TahunAkhir != "2029" {
+		t.Errorf("periode 2026 = %+v, %v, %v", p, ok, err)
+	}
+	if _, ok, err := getPeriodeByTahun(context.Background(), 2040); err != nil || ok {
+		t.Errorf("periode 2040 ok %v err %v, want tidak ditemukan tanpa error", ok, err)
+	}
+}
+
+func TestNewTargetRowEmptyCells(t *testing.T) {
+	ind := IndikatorPohon{IdIndikator: "IND-1", Indikator: "Prevalensi stunting", Target: []TargetIndikator{
+		targetAngka(2025, "20", "%"),
+		targetAngka(2027, "16", "%"),
+		// target dobel di tahun yang sama, yang pertama dipakai
+		targetAngka(2027, "15", "%"),
+	}}
+	row := newTargetRow(ind, sumberPohon, PohonKinerjaPemda{IdPohon: 7}, 2, []int{2025, 2026, 2027, 2028})
+
+	if row.Satuan != "%" || row.Kosong != 2 || len(row.Targets) != 4 {
+		t.Fatalf("row = %+v", row)
+	}
+	if c := row.Targets[2]; !c.Terisi || *c.Target != "16" || *c.Nilai != 16 {
+		t.Errorf("sel 2027 = %+v, want 16", c)
+	}
+
+	// tahun kosong tetap dikirim dengan target null
+	data, err := json.Marshal(row.Targets[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"tahun":2026,"target":null,"nilai":null,"terisi":false}` {
+		t.Errorf("sel kosong = %s", data)
+	}
+}
+
+func TestCollectTargetRowsDedup(t *testing.T) {
+	indKeg := IndikatorPohon{IdIndikator: "KEG-1", Indikator: "Indikator kegiatan"}
+	tree := []PohonKinerjaPemda{{
+		IdPohon:    1,
+		Indikators: []IndikatorPohon{{IdIndikator: "POH-1", Indikator: "Indikator tematik"}},
+		Childs: []PohonKinerjaPemda{{
+			IdPohon:      2,
+			ProgramPokin: []Program{{IndikatorProgram: []IndikatorPohon{{IdIndikator: "PRG-1"}}}},
+			// indikator kegiatan yang sama di dua rekin
+			RencanaKinerjas: []RencanaKinerjaAsn{
+				{IdRekin: "R1", IndikatorKegiatan: []IndikatorPohon{indKeg}},
+				{IdRekin: "R2", IndikatorKegiatan: []IndikatorPohon{indKeg},
+					IndikatorSubkegiatan: []IndikatorPohon{{IdIndikator: "SUB-1"}}},
+			},
+		}},
+	}}
+
+	rows := collectTargetRows(tree, 1, []int{2025}, make(map[string]bool), nil)
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.IdIndikator+"/"+r.Sumber)
+	}
+	want := []string{"POH-1/pohon", "PRG-1/program", "KEG-1/kegiatan", "SUB-1/subkegiatan"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if rows[0].Level != 1 || rows[1].Level != 2 || rows[0].Kosong != 1 {
+		t.Errorf("level/kosong = %+v", rows)
+	}
+}
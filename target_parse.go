@@ -0,0 +1,116 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// status hasil parsing target/realisasi
+const (
+	parseOK         = "ok"
+	parseKosong     = "kosong"
+	parseTidakValid = "tidak_valid"
+)
+
+// ParsedNumber adalah angka hasil parsing teks target atau realisasi.
+type ParsedNumber struct {
+	Nilai  *float64 `json:"nilai"`
+	Persen bool     `json:"persen,omitempty"`
+	Unit   string   `json:"unit,omitempty"`
+	Status string   `json:"parse_status"`
+}
+
+var numberPattern = regexp.MustCompile(`[-+]?\d[\d.,]*`)
+
+// parseAngka membaca angka bebas seperti "12,5", "95%", "1.200",
+// "1.200,50" atau "Rp 2.500.000". Format Indonesia didahulukan: titik
+// pemisah ribuan dan koma pemisah desimal. Satuan dipakai untuk
+// mengenali persen bila teksnya sendiri tidak memuat "%".
+func parseAngka(raw, satuan string) ParsedNumber {
+	s := strings.TrimSpace(raw)
+	if s == "" || s == "-" {
+		return ParsedNumber{Status: parseKosong}
+	}
+
+	lower := strings.ToLower(s)
+	lower = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(lower, "rp."), "rp"))
+
+	tokens := numberPattern.FindAllString(lower, -1)
+	if len(tokens) != 1 {
+		// tanpa angka, atau rentang seperti "10-12"
+		return ParsedNumber{Status: parseTidakValid}
+	}
+	tok := strings.TrimRight(tokens[0], ".,")
+
+	value, ok := normalizeAngka(tok)
+	if !ok {
+		return ParsedNumber{Status: parseTidakValid}
+	}
+
+	unit := strings.TrimSpace(strings.Replace(lower, tokens[0], "", 1))
+	persen := strings.Contains(unit, "%") || isPersen(satuan)
+	if unit == "" {
+		unit = strings.TrimSpace(satuan)
+	}
+
+	return ParsedNumber{Nilai: &value, Persen: persen, Unit: unit, Status: parseOK}
+}
+
+func isPersen(satuan string) bool {
+	s := strings.ToLower(satuan)
+	return strings.Contains(s, "%") || strings.Contains(s, "persen")
+}
+
+// normalizeAngka mengubah "1.200,5" menjadi 1200.5. Bila hanya ada titik,
+// titik dianggap pemisah ribuan selama bagian bulatnya bukan nol dan setiap
+// kelompok tepat tiga digit, selain itu dianggap desimal ("1.5" atau
+// "0.500" dari input gaya Inggris).
+func normalizeAngka(tok string) (float64, bool) {
+	dot := strings.Count(tok, ".")
+	comma := strings.Count(tok, ",")
+
+	switch {
+	case dot > 0 && comma > 0:
+		// pemisah terakhir adalah desimal
+		if strings.LastIndex(tok, ",") > strings.LastIndex(tok, ".") {
+			tok = strings.ReplaceAll(tok, ".", "")
+			tok = strings.Replace(tok, ",", ".", 1)
+		} else {
+			tok = strings.ReplaceAll(tok, ",", "")
+		}
+	case comma == 1:
+		tok = strings.Replace(tok, ",", ".", 1)
+	case comma > 1:
+		if !thousandGroups(tok, ",") {
+			return 0, false
+		}
+		tok = strings.ReplaceAll(tok, ",", "")
+	case dot > 1 || (dot == 1 && thousandGroups(tok, ".")):
+		if !thousandGroups(tok, ".") {
+			return 0, false
+		}
+		tok = strings.ReplaceAll(tok, ".", "")
+	}
+
+	v, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// thousandGroups memeriksa tok berpola ribuan: kelompok pertama 1-3 digit
+// tanpa nol di depan, sisanya tepat tiga digit.
+func thousandGroups(tok, sep string) bool {
+	parts := strings.Split(strings.TrimLeft(tok, "+-"), sep)
+	if len(parts[0]) == 0 || len(parts[0]) > 3 || parts[0][0] == '0' {
+		return false
+	}
+	for _, p := range parts[1:] {
+		if len(p) != 3 {
+			return false
+		}
+	}
+	return true
+}
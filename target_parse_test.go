@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestParseAngka(t *testing.T) {
+	tests := []struct {
+		raw    string
+		satuan string
+		want   float64
+		persen bool
+		status string
+	}{
+		{"1.234,5", "", 1234.5, false, parseOK},
+		{"75%", "", 75, true, parseOK},
+		{"0,5", "", 0.5, false, parseOK},
+		{"12,5", "persen", 12.5, true, parseOK},
+		{"1.200", "unit", 1200, false, parseOK},
+		{"1.200.000", "", 1200000, false, parseOK},
+		{"0.500", "", 0.5, false, parseOK},
+		{"1.5", "", 1.5, false, parseOK},
+		{"1,234.5", "", 1234.5, false, parseOK},
+		{"Rp 2.500.000", "", 2500000, false, parseOK},
+		{"-3,25", "", -3.25, false, parseOK},
+		{"", "", 0, false, parseKosong},
+		{"  ", "", 0, false, parseKosong},
+		{"-", "", 0, false, parseKosong},
+		{"10-12", "", 0, false, parseTidakValid},
+		{"10 s.d. 12", "", 0, false, parseTidakValid},
+		{"meningkat", "", 0, false, parseTidakValid},
+		{"1.23.4", "", 0, false, parseTidakValid},
+	}
+	for _, tt := range tests {
+		got := parseAngka(tt.raw, tt.satuan)
+		if got.Status != tt.status {
+			t.Errorf("parseAngka(%q) status = %s, want %s", tt.raw, got.Status, tt.status)
+			continue
+		}
+		if tt.status != parseOK {
+			if got.Nilai != nil {
+				t.Errorf("parseAngka(%q) nilai = %v, want nil", tt.raw, *got.Nilai)
+			}
+			continue
+		}
+		if got.Nilai == nil || *got.Nilai != tt.want || got.Persen != tt.persen {
+			t.Errorf("parseAngka(%q) = %+v, want %v persen=%v", tt.raw, got, tt.want, tt.persen)
+		}
+	}
+}
+
+func TestHitungCapaianArah(t *testing.T) {
+	target := 10.0
+	ind := IndikatorPohon{IdIndikator: "IND-1", Target: []TargetIndikator{{Target: "10", Nilai: &target, Tahun: 2025}}}
+
+	tests := []struct {
+		realisasi realisasiIndikator
+		want      float64
+	}{
+		{realisasiIndikator{Nilai: "8", Arah: arahNaik}, 80},
+		{realisasiIndikator{Nilai: "8", Arah: arahTurun}, 120},
+		{realisasiIndikator{Nilai: "12", Arah: arahTurun}, 80},
+	}
+	for _, tt := range tests {
+		c := hitungCapaian(ind, 2025, map[string]realisasiIndikator{"IND-1": tt.realisasi}, true)
+		if c.Status != capaianOK || c.Capaian == nil || *c.Capaian != tt.want {
+			t.Errorf("realisasi %+v: capaian = %v (%s), want %v", tt.realisasi, c.Capaian, c.Status, tt.want)
+		}
+	}
+
+	if c := hitungCapaian(ind, 2025, nil, false); c.Status != capaianTanpaRealisasi || c.Capaian != nil {
+		t.Errorf("tanpa tabel realisasi status = %s, want %s", c.Status, capaianTanpaRealisasi)
+	}
+}
+
+func TestParseArah(t *testing.T) {
+	for v, want := range map[string]string{"": arahNaik, "naik": arahNaik, "Turun": arahTurun, " menurun ": arahTurun, "negatif": arahTurun} {
+		if got := parseArah(v); got != want {
+			t.Errorf("parseArah(%q) = %s, want %s", v, got, want)
+		}
+	}
+}